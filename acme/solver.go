@@ -0,0 +1,181 @@
+// Package acme solves ACME DNS-01 challenges with Hosttech.ch and waits until the nameservers serve the challenge
+// records. It is kept apart from the provider, so importers of the provider do not depend on a DNS client.
+package acme
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// The label of the TXT record used for DNS-01 challenges
+const challengeLabel = "_acme-challenge"
+
+// Provider creates, lists and deletes the challenge records of a zone.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordDeleter
+}
+
+// Solver creates, verifies and removes the TXT records of ACME DNS-01 challenges. Present only returns once
+// the record is served by all nameservers, so the validation server does not query before the record is visible.
+type Solver struct {
+	Provider Provider
+
+	// Resolvers are the addresses ("host:port") of the nameservers that are polled for the challenge record.
+	// Defaults to the authoritative nameservers of the zone.
+	Resolvers []string
+
+	// PollInterval is the time between two checks of the nameservers. Defaults to 5 seconds.
+	PollInterval time.Duration
+
+	// PropagationTimeout is the time to wait for the record to be visible on all nameservers. Defaults to 5 minutes.
+	PropagationTimeout time.Duration
+}
+
+// Present creates the challenge TXT record for the domain and waits until all nameservers serve it.
+// The domain may be the zone itself, a subdomain of it or a wildcard.
+func (s *Solver) Present(ctx context.Context, zone string, domain string, value string) error {
+	_, err := s.Provider.AppendRecords(ctx, zone, []libdns.Record{
+		{
+			Type:  "TXT",
+			Name:  challengeName(zone, domain),
+			Value: value,
+			TTL:   hosttech.MinimalTTL,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.WaitForPropagation(ctx, zone, domain, value)
+}
+
+// WaitForPropagation polls the nameservers until all of them serve the challenge TXT record with the value.
+func (s *Solver) WaitForPropagation(ctx context.Context, zone string, domain string, value string) error {
+	timeout := s.PropagationTimeout
+	if timeout == 0 {
+		timeout = 5 * time.Minute
+	}
+	interval := s.PollInterval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resolvers := s.Resolvers
+	if len(resolvers) == 0 {
+		var err error
+		resolvers, err = authoritativeNameservers(ctx, zone)
+		if err != nil {
+			return err
+		}
+	}
+
+	fqdn := dns.Fqdn(libdns.AbsoluteName(challengeName(zone, domain), zone))
+	for {
+		pending, err := pendingResolvers(ctx, resolvers, fqdn, value)
+		if err == nil && len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("challenge record '%s' did not propagate: %w", fqdn, err)
+			}
+			return fmt.Errorf("challenge record '%s' did not propagate to %s", fqdn, strings.Join(pending, ", "))
+		case <-time.After(interval):
+		}
+	}
+}
+
+// CleanUp deletes all challenge TXT records of the domain with the value. Other challenge records are kept, so
+// concurrent challenges for the same domain do not interfere.
+func (s *Solver) CleanUp(ctx context.Context, zone string, domain string, value string) error {
+	records, err := s.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	name := hosttech.NormalizeName(challengeName(zone, domain), zone)
+	var challengeRecords []libdns.Record
+	for _, record := range records {
+		if record.Type == "TXT" && hosttech.NormalizeName(record.Name, zone) == name && record.Value == value {
+			challengeRecords = append(challengeRecords, record)
+		}
+	}
+
+	_, err = s.Provider.DeleteRecords(ctx, zone, challengeRecords)
+	return err
+}
+
+// challengeName returns the name of the challenge record relative to the zone.
+func challengeName(zone string, domain string) string {
+	domain = strings.TrimPrefix(strings.TrimSuffix(domain, "."), "*.")
+	name := hosttech.NormalizeName(domain, zone)
+	if name == "" {
+		return challengeLabel
+	}
+	return challengeLabel + "." + name
+}
+
+// pendingResolvers returns the resolvers that do not serve the TXT record with the value yet.
+func pendingResolvers(ctx context.Context, resolvers []string, fqdn string, value string) ([]string, error) {
+	message := new(dns.Msg)
+	message.SetQuestion(fqdn, dns.TypeTXT)
+	message.RecursionDesired = false
+
+	client := new(dns.Client)
+	var pending []string
+	for _, resolver := range resolvers {
+		response, _, err := client.ExchangeContext(ctx, message, resolver)
+		if err != nil {
+			return nil, err
+		}
+
+		if !containsTXT(response.Answer, value) {
+			pending = append(pending, resolver)
+		}
+	}
+
+	return pending, nil
+}
+
+func containsTXT(answers []dns.RR, value string) bool {
+	for _, answer := range answers {
+		txt, ok := answer.(*dns.TXT)
+		if ok && strings.Join(txt.Txt, "") == value {
+			return true
+		}
+	}
+	return false
+}
+
+// authoritativeNameservers looks up the addresses of the nameservers of the zone.
+func authoritativeNameservers(ctx context.Context, zone string) ([]string, error) {
+	nameservers, err := net.DefaultResolver.LookupNS(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, nameserver := range nameservers {
+		addresses = append(addresses, net.JoinHostPort(strings.TrimSuffix(nameserver.Host, "."), "53"))
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no nameservers found for zone '%s'", zone)
+	}
+
+	return addresses, nil
+}
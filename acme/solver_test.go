@@ -0,0 +1,102 @@
+package acme
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChallengeName(t *testing.T) {
+	input := map[string]struct {
+		expectedResult string
+		domain         string
+	}{
+		"Apex Test":         {expectedResult: "_acme-challenge", domain: "example.com"},
+		"Subdomain Test":    {expectedResult: "_acme-challenge.sub", domain: "sub.example.com"},
+		"Wildcard Test":     {expectedResult: "_acme-challenge.sub", domain: "*.sub.example.com"},
+		"Trailing Dot Test": {expectedResult: "_acme-challenge.www", domain: "www.example.com."},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testStruct.expectedResult, challengeName("example.com", testStruct.domain))
+		})
+	}
+}
+
+func TestSolver_PresentAndCleanUp(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+
+	address, err := api.StartDNS("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	solver := Solver{
+		Provider:           &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Resolvers:          []string{address},
+		PollInterval:       10 * time.Millisecond,
+		PropagationTimeout: time.Second,
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, solver.Present(ctx, "example.com", "*.www.example.com", "first"))
+	assert.NoError(t, solver.Present(ctx, "example.com", "www.example.com", "second"))
+	assert.NoError(t, solver.CleanUp(ctx, "example.com", "WWW.example.com.", "first"))
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 2)
+	assert.Equal(t, "_acme-challenge.www", records[1]["name"])
+	assert.Equal(t, "second", records[1]["text"])
+	assert.Equal(t, float64(600), records[1]["ttl"])
+}
+
+func TestSolver_WaitForPropagation(t *testing.T) {
+	//The stand-in nameserver only serves the record from the third query on
+	var queries atomic.Int32
+	address := startTestNameserver(t, func(w dns.ResponseWriter, r *dns.Msg) {
+		response := new(dns.Msg)
+		response.SetReply(r)
+		if queries.Add(1) >= 3 {
+			response.Answer = append(response.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 600},
+				Txt: []string{"challenge-value"},
+			})
+		}
+		w.WriteMsg(response)
+	})
+
+	solver := Solver{
+		Resolvers:          []string{address},
+		PollInterval:       10 * time.Millisecond,
+		PropagationTimeout: time.Second,
+	}
+
+	err := solver.WaitForPropagation(context.Background(), "example.com", "sub.example.com", "challenge-value")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), queries.Load())
+
+	solver.PropagationTimeout = 50 * time.Millisecond
+	err = solver.WaitForPropagation(context.Background(), "example.com", "sub.example.com", "other-value")
+	assert.ErrorContains(t, err, "did not propagate")
+}
+
+func startTestNameserver(t *testing.T, handler dns.HandlerFunc) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{PacketConn: conn, Handler: handler}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String()
+}
@@ -0,0 +1,348 @@
+// Package acmedns implements the HTTP API of acme-dns (https://github.com/joohoi/acme-dns) on top of the Hosttech.ch
+// libdns provider, for ACME clients that can only solve DNS-01 challenges through acme-dns. Instead of serving the
+// challenge records itself, the server writes them to a Hosttech zone.
+//
+// Clients register an account, point a CNAME record from _acme-challenge.<their domain> to the full domain of the
+// account and update its TXT records with the account credentials.
+package acmedns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+)
+
+// Like acme-dns, the two most recent values are kept, so a certificate for a domain and its wildcard can be
+// validated at the same time.
+const keptValues = 2
+
+// Provider writes the TXT records of the accounts, replacing the oldest value once keptValues are reached.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordDeleter
+}
+
+// Server serves the acme-dns API.
+type Server struct {
+	Provider Provider
+
+	// Zone is the Hosttech zone the challenge records are written to
+	Zone string
+
+	// Domain is the domain the subdomains of the accounts are created under, e.g. acme.example.com. It has to be
+	// the zone or a subdomain of it. Defaults to the zone.
+	Domain string
+
+	// RegistrationsFile is where the accounts are persisted. Without it, the accounts are lost on restart.
+	RegistrationsFile string
+
+	// DisableRegistration turns off /register, so only the accounts already in the registrations file can be used
+	DisableRegistration bool
+
+	// TTL of the challenge records. The CA looks them up right after an update, so it defaults to the lowest TTL
+	// Hosttech accepts, hosttech.MinimalTTL.
+	TTL time.Duration
+
+	// Logger receives the failures behind the errors returned to acme-dns clients, which only see a generic message.
+	// Without it, log.Default() is used.
+	Logger *log.Logger
+
+	initOnce sync.Once
+	store    *store
+
+	//Updates are serialized, as every update reads and rewrites the values of an account
+	updateMu sync.Mutex
+}
+
+// Handler returns the HTTP handler serving POST /register, POST /update and GET /health.
+func (s *Server) Handler() http.Handler {
+	s.initOnce.Do(func() {
+		s.store = &store{path: s.RegistrationsFile}
+	})
+
+	mux := http.NewServeMux()
+	if !s.DisableRegistration {
+		mux.HandleFunc("POST /register", s.register)
+	}
+	mux.HandleFunc("POST /update", s.update)
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+type registerRequest struct {
+	AllowFrom []string `json:"allowfrom"`
+}
+
+type registerResponse struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	FullDomain string   `json:"fulldomain"`
+	Subdomain  string   `json:"subdomain"`
+	AllowFrom  []string `json:"allowfrom"`
+}
+
+type updateRequest struct {
+	Subdomain string `json:"subdomain"`
+	TXT       string `json:"txt"`
+}
+
+type updateResponse struct {
+	TXT string `json:"txt"`
+}
+
+// register creates an account with random credentials and subdomain. The body is optional and may restrict the
+// networks updates are accepted from.
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	request := registerRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "malformed_json_payload")
+		return
+	}
+
+	allowFrom := []string{}
+	for _, cidr := range request.AllowFrom {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_allowfrom_cidr")
+			return
+		}
+		allowFrom = append(allowFrom, prefix.Masked().String())
+	}
+
+	password := randomPassword()
+	registration := Registration{
+		Username:     randomUUID(),
+		PasswordHash: hashPassword(password),
+		Subdomain:    randomUUID(),
+		AllowFrom:    allowFrom,
+	}
+
+	err = s.store.put(registration)
+	if err != nil {
+		s.logger().Printf("could not store registration: %s", err)
+		writeError(w, http.StatusInternalServerError, "db_error")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, registerResponse{
+		Username:   registration.Username,
+		Password:   password,
+		FullDomain: s.fullDomain(registration.Subdomain),
+		Subdomain:  registration.Subdomain,
+		AllowFrom:  allowFrom,
+	})
+}
+
+// update sets a TXT record of the subdomain of the account given in the X-Api-User and X-Api-Key headers.
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	registration, ok, err := s.store.get(r.Header.Get("X-Api-User"))
+	if err != nil {
+		s.logger().Printf("could not read registration: %s", err)
+		writeError(w, http.StatusInternalServerError, "db_error")
+		return
+	}
+	if !ok || !registration.checkPassword(r.Header.Get("X-Api-Key")) || !allowed(registration, r.RemoteAddr) {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+
+	request := updateRequest{}
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "malformed_json_payload")
+		return
+	}
+
+	if request.Subdomain != registration.Subdomain {
+		writeError(w, http.StatusUnauthorized, "forbidden")
+		return
+	}
+
+	if !validTXT(request.TXT) {
+		writeError(w, http.StatusBadRequest, "bad_txt")
+		return
+	}
+
+	err = s.updateTXT(r.Context(), registration.Username, request.TXT)
+	if err != nil {
+		s.logger().Printf("could not update TXT record of '%s': %s", s.fullDomain(registration.Subdomain), err)
+		writeError(w, http.StatusInternalServerError, "db_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updateResponse{TXT: request.TXT})
+}
+
+// updateTXT adds a TXT record with the value to the subdomain of the account and deletes the records of values that
+// are no longer kept.
+func (s *Server) updateTXT(ctx context.Context, username string, value string) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	//Read again under the lock, another update may have changed the values in the meantime
+	registration, _, err := s.store.get(username)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range registration.TXT {
+		if existing == value {
+			return nil
+		}
+	}
+
+	zone := s.zone()
+	name := libdns.RelativeName(s.fullDomain(registration.Subdomain), zone)
+	_, err = s.Provider.AppendRecords(ctx, zone, []libdns.Record{
+		{Type: "TXT", Name: name, Value: value, TTL: s.ttl()},
+	})
+	if err != nil {
+		return err
+	}
+
+	values := append(slices.Clone(registration.TXT), value)
+	removed := values[:max(len(values)-keptValues, 0)]
+	registration.TXT = values[len(removed):]
+
+	//The new value is stored before the old records are deleted, so a failed delete never loses the new record
+	err = s.store.put(registration)
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	records, err := s.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	var removedRecords []libdns.Record
+	for _, record := range records {
+		if record.Type != "TXT" || !strings.EqualFold(record.Name, name) {
+			continue
+		}
+		for _, removedValue := range removed {
+			if record.Value == removedValue {
+				removedRecords = append(removedRecords, record)
+			}
+		}
+	}
+
+	_, err = s.Provider.DeleteRecords(ctx, zone, removedRecords)
+	return err
+}
+
+// allowed reports whether the client address is in one of the networks of the account.
+func allowed(registration Registration, remoteAddr string) bool {
+	if len(registration.AllowFrom) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	address, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	address = address.Unmap()
+
+	for _, cidr := range registration.AllowFrom {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err == nil && prefix.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// validTXT reports whether the value looks like a DNS-01 key authorization digest, an unpadded base64url encoded
+// SHA-256 hash.
+func validTXT(value string) bool {
+	if len(value) != 43 {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(value)
+	return err == nil
+}
+
+func (s *Server) fullDomain(subdomain string) string {
+	return subdomain + "." + s.domain()
+}
+
+func (s *Server) zone() string {
+	return strings.TrimSuffix(s.Zone, ".")
+}
+
+func (s *Server) domain() string {
+	if s.Domain == "" {
+		return s.zone()
+	}
+	return strings.ToLower(strings.TrimSuffix(s.Domain, "."))
+}
+
+func (s *Server) ttl() time.Duration {
+	if s.TTL == 0 {
+		return hosttech.MinimalTTL
+	}
+	return s.TTL
+}
+
+func (s *Server) logger() *log.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return log.Default()
+}
+
+// randomUUID returns a random version 4 UUID, used for usernames and subdomains.
+func randomUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// randomPassword returns a random password of 40 characters, like the ones generated by acme-dns.
+func randomPassword() string {
+	b := make([]byte, 30)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Interface guard
+var _ Provider = (*hosttech.Provider)(nil)
@@ -0,0 +1,111 @@
+package acmedns
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+
+	registrationsFile := filepath.Join(t.TempDir(), "registrations.json")
+	newServer := func() http.Handler {
+		server := &Server{
+			Provider:          &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+			Zone:              "example.com",
+			Domain:            "acme.example.com",
+			RegistrationsFile: registrationsFile,
+			Logger:            log.New(io.Discard, "", 0),
+		}
+		return server.Handler()
+	}
+	handler := newServer()
+
+	response := serve(handler, http.MethodPost, "/register", "", nil)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	account := registerResponse{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &account))
+	assert.Equal(t, account.Subdomain+".acme.example.com", account.FullDomain)
+	assert.Len(t, account.Password, 40)
+
+	credentials := map[string]string{"X-Api-User": account.Username, "X-Api-Key": account.Password}
+	update := func(handler http.Handler, txt string, headers map[string]string) *httptest.ResponseRecorder {
+		return serve(handler, http.MethodPost, "/update", `{"subdomain": "`+account.Subdomain+`", "txt": "`+txt+`"}`, headers)
+	}
+
+	first := strings.Repeat("a", 43)
+	second := strings.Repeat("b", 43)
+	third := strings.Repeat("c", 43)
+
+	response = update(handler, first, map[string]string{"X-Api-User": account.Username, "X-Api-Key": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = update(handler, "too short", credentials)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "bad_txt"}`, response.Body.String())
+
+	response = update(handler, first, credentials)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"txt": "`+first+`"}`, response.Body.String())
+	assert.Equal(t, http.StatusOK, update(handler, second, credentials).Code)
+
+	//The account survives a restart and only the two most recent values are kept
+	restarted := newServer()
+	assert.Equal(t, http.StatusOK, update(restarted, third, credentials).Code)
+
+	var values []string
+	for _, record := range api.Records("example.com") {
+		assert.Equal(t, "TXT", record["type"])
+		assert.Equal(t, account.Subdomain+".acme", record["name"])
+		values = append(values, record["text"].(string))
+	}
+	assert.ElementsMatch(t, []string{second, third}, values)
+}
+
+func TestServer_AllowFrom(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+
+	server := &Server{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Zone:     "example.com",
+		Logger:   log.New(io.Discard, "", 0),
+	}
+	handler := server.Handler()
+
+	response := serve(handler, http.MethodPost, "/register", `{"allowfrom": ["not a network"]}`, nil)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = serve(handler, http.MethodPost, "/register", `{"allowfrom": ["10.0.0.0/8"]}`, nil)
+	assert.Equal(t, http.StatusCreated, response.Code)
+	account := registerResponse{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &account))
+
+	//httptest requests come from 192.0.2.1
+	body := `{"subdomain": "` + account.Subdomain + `", "txt": "` + strings.Repeat("a", 43) + `"}`
+	response = serve(handler, http.MethodPost, "/update", body, map[string]string{"X-Api-User": account.Username, "X-Api-Key": account.Password})
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Empty(t, api.Records("example.com"))
+}
+
+func serve(handler http.Handler, method string, target string, body string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
@@ -0,0 +1,131 @@
+package acmedns
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/libdns/hosttech/internal/atomicfile"
+)
+
+// Registration is an account created through /register. It may update the TXT records of its subdomain.
+type Registration struct {
+	Username string `json:"username"`
+
+	// PasswordHash is the hex encoded SHA-256 hash of the password. The passwords are random and long enough that a
+	// slow hash does not add any protection.
+	PasswordHash string `json:"passwordHash"`
+
+	Subdomain string `json:"subdomain"`
+
+	// AllowFrom are the networks updates are accepted from. Updates are accepted from anywhere if it is empty.
+	AllowFrom []string `json:"allowFrom,omitempty"`
+
+	// TXT are the values of the TXT records of the subdomain, oldest first
+	TXT []string `json:"txt,omitempty"`
+}
+
+// checkPassword compares the password with the hash in constant time.
+func (r Registration) checkPassword(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.PasswordHash), []byte(hashPassword(password))) == 1
+}
+
+func hashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
+}
+
+// store keeps the registrations in memory and persists them to a JSON file, if one is configured.
+type store struct {
+	path string
+
+	mu            sync.Mutex
+	registrations map[string]Registration
+}
+
+func (s *store) get(username string) (Registration, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return Registration{}, false, err
+	}
+
+	registration, ok := s.registrations[username]
+	return registration, ok, nil
+}
+
+func (s *store) put(registration Registration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	previous, existed := s.registrations[registration.Username]
+	s.registrations[registration.Username] = registration
+
+	err = s.save()
+	if err != nil {
+		//Keep memory and file consistent, so a failed write is not served until the next restart
+		if existed {
+			s.registrations[registration.Username] = previous
+		} else {
+			delete(s.registrations, registration.Username)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) load() error {
+	if s.registrations != nil {
+		return nil
+	}
+
+	registrations := map[string]Registration{}
+	if s.path != "" {
+		content, err := os.ReadFile(s.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not read registrations: %w", err)
+		}
+
+		if err == nil {
+			err = json.Unmarshal(content, &registrations)
+			if err != nil {
+				return fmt.Errorf("could not parse registrations file '%s': %w", s.path, err)
+			}
+		}
+	}
+
+	s.registrations = registrations
+	return nil
+}
+
+// save writes the registrations to the file, replacing it atomically.
+func (s *store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s.registrations, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(s.path, content, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write registrations: %w", err)
+	}
+
+	return nil
+}
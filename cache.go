@@ -0,0 +1,164 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+)
+
+// cachedZone holds the records of a zone as they were last read from the API, kept up to date with the writes of the
+// provider.
+type cachedZone struct {
+	records []HosttechRecordWrapper
+	expires time.Time
+
+	// generation is incremented by every write, so a fetch that raced a write does not store what it read
+	generation int
+
+	// fetch is the request that is currently reading the zone, shared by all callers that miss the cache
+	fetch *cacheFetch
+}
+
+// cacheFetch is a single read of a zone that concurrent callers wait for.
+type cacheFetch struct {
+	done    chan struct{}
+	records []HosttechRecordWrapper
+	err     error
+}
+
+func (c *cachedZone) valid() bool {
+	return c.records != nil && time.Now().Before(c.expires)
+}
+
+// ClearCache drops the cached records of the zone, so the next read goes to the API. Without a zone, the cache of all
+// zones is cleared.
+func (p *Provider) ClearCache(zone string) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	for name, cached := range p.cacheZones {
+		if zone == "" || name == zoneKey(zone) {
+			cached.records = nil
+			cached.generation++
+		}
+	}
+}
+
+// getCachedHosttechRecords serves the records of the zone from the cache if caching is enabled. On a miss, concurrent
+// callers share a single request to the API, whose result is cached for CacheTTL.
+func (p *Provider) getCachedHosttechRecords(ctx context.Context, zone string) ([]HosttechRecordWrapper, error) {
+	if p.CacheTTL <= 0 {
+		return p.getHosttechRecords(ctx, zone)
+	}
+
+	p.cacheMu.Lock()
+	cached := p.cachedZone(zone)
+	if cached.valid() {
+		records := slices.Clone(cached.records)
+		p.cacheMu.Unlock()
+		return records, nil
+	}
+
+	fetch := cached.fetch
+	if fetch == nil {
+		fetch = &cacheFetch{done: make(chan struct{})}
+		cached.fetch = fetch
+		go p.fetchZone(context.WithoutCancel(ctx), zone, fetch, cached.generation)
+	}
+	p.cacheMu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return slices.Clone(fetch.records), fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchZone reads the zone for all waiting callers. The result is only cached if no write happened in the meantime.
+// The fetch is detached from the cancellation of the caller that started it, because other callers may still wait for it.
+func (p *Provider) fetchZone(ctx context.Context, zone string, fetch *cacheFetch, generation int) {
+	fetch.records, fetch.err = p.getHosttechRecords(ctx, zone)
+
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	cached := p.cachedZone(zone)
+	cached.fetch = nil
+	if fetch.err == nil && cached.generation == generation {
+		cached.records = fetch.records
+		if cached.records == nil {
+			cached.records = []HosttechRecordWrapper{}
+		}
+		cached.expires = time.Now().Add(p.CacheTTL)
+	}
+	close(fetch.done)
+}
+
+// updateCache applies a mutating API call to the cached records of the zone. Created and updated records are taken
+// from the response, deleted records are removed. If the call failed, the zone is dropped from the cache, because it
+// is unknown whether the change reached the API.
+func (p *Provider) updateCache(zone string, httpMethod string, reqUrl string, response []byte, err error) {
+	if p.CacheTTL <= 0 || p.DryRun || httpMethod == http.MethodGet || zone == "" {
+		return
+	}
+
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	cached := p.cachedZone(zone)
+	cached.generation++
+	if !cached.valid() {
+		return
+	}
+
+	if err != nil {
+		cached.records = nil
+		return
+	}
+
+	if httpMethod == http.MethodDelete {
+		recordId := path.Base(reqUrl)
+		cached.records = slices.DeleteFunc(slices.Clone(cached.records), func(record HosttechRecordWrapper) bool {
+			return record.toLibdnsRecord(zone).ID == recordId
+		})
+		return
+	}
+
+	var parsedResponse = HosttechSingleResponseWrapper{}
+	if json.Unmarshal(response, &parsedResponse) != nil || parsedResponse.Data.value == nil {
+		cached.records = nil
+		return
+	}
+
+	written := parsedResponse.Data
+	writtenId := written.toLibdnsRecord(zone).ID
+	records := slices.Clone(cached.records)
+	index := slices.IndexFunc(records, func(record HosttechRecordWrapper) bool {
+		return record.toLibdnsRecord(zone).ID == writtenId
+	})
+	if index >= 0 {
+		records[index] = written
+	} else {
+		records = append(records, written)
+	}
+	cached.records = records
+}
+
+// cachedZone returns the cache entry of the zone, creating it if needed. The cache mutex must be held.
+func (p *Provider) cachedZone(zone string) *cachedZone {
+	if p.cacheZones == nil {
+		p.cacheZones = map[string]*cachedZone{}
+	}
+
+	cached, ok := p.cacheZones[zoneKey(zone)]
+	if !ok {
+		cached = &cachedZone{}
+		p.cacheZones[zoneKey(zone)] = cached
+	}
+
+	return cached
+}
@@ -0,0 +1,152 @@
+package hosttech
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Cache(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	reads := countReads(api)
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CacheTTL: time.Minute}
+	ctx := context.Background()
+
+	//Concurrent reads share one request
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records, err := provider.GetRecords(ctx, "example.com")
+			assert.NoError(t, err)
+			assert.Len(t, records, 1)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, reads.Load())
+
+	//Writes of the provider update the cache
+	appended, err := provider.AppendRecords(ctx, "example.com", []libdns.Record{{Type: "TXT", Name: "_acme-challenge", Value: "token", TTL: 600 * time.Second}})
+	assert.NoError(t, err)
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	updated := records[0]
+	updated.Value = "1.2.3.5"
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{updated})
+	assert.NoError(t, err)
+	records, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.5", records[0].Value)
+
+	_, err = provider.DeleteRecords(ctx, "example.com", appended)
+	assert.NoError(t, err)
+	records, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.EqualValues(t, 1, reads.Load())
+
+	//Changes made elsewhere show up once the cache is cleared
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.6", "ttl": 3600})
+	records, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	provider.ClearCache("example.com")
+	records, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.EqualValues(t, 2, reads.Load())
+
+	//Failed writes drop the zone from the cache
+	_, err = provider.DeleteRecords(ctx, "example.com", []libdns.Record{{ID: "999"}})
+	assert.Error(t, err)
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, reads.Load())
+
+	//Errors are not cached
+	_, err = provider.GetRecords(ctx, "unknown.com")
+	assert.Error(t, err)
+	_, err = provider.GetRecords(ctx, "unknown.com")
+	assert.Error(t, err)
+	assert.EqualValues(t, 5, reads.Load())
+}
+
+func TestProvider_CacheExpires(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	reads := countReads(api)
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CacheTTL: 10 * time.Millisecond}
+	_, err := provider.GetRecords(context.Background(), "example.com")
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = provider.GetRecords(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, reads.Load())
+}
+
+func TestProvider_CacheZoneSpelling(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	reads := countReads(api)
+
+	canonicalZonePaths(api)
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CacheTTL: time.Minute}
+	ctx := context.Background()
+
+	//A write through one spelling of the zone updates the records cached under another
+	records, err := provider.GetRecords(ctx, "example.com.")
+	assert.NoError(t, err)
+	records[0].Value = "1.2.3.5"
+	_, err = provider.SetRecords(ctx, "example.com", records)
+	assert.NoError(t, err)
+
+	records, err = provider.GetRecords(ctx, "Example.com.")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.5", records[0].Value)
+	assert.EqualValues(t, 1, reads.Load())
+
+	provider.ClearCache("example.com.")
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, reads.Load())
+}
+
+// canonicalZonePaths lets the fake API accept other spellings of the zone "example.com" in request paths.
+func canonicalZonePaths(api *hosttechtest.Server) {
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = strings.Replace(strings.ToLower(r.URL.Path), "example.com./", "example.com/", 1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// countReads counts the requests listing the records of a zone.
+func countReads(api *hosttechtest.Server) *atomic.Int32 {
+	reads := &atomic.Int32{}
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			reads.Add(1)
+		}
+		next.ServeHTTP(w, r)
+	})
+	return reads
+}
@@ -0,0 +1,76 @@
+package caddy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/libdns/hosttech"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_UnmarshalCaddyfile(t *testing.T) {
+	input := map[string]struct {
+		expectedToken string
+		expectedError bool
+		data          string
+	}{
+		"Inline Token Test": {
+			expectedToken: "my-token",
+			data:          `hosttech my-token`,
+		},
+		"Block Token Test": {
+			expectedToken: "{env.HOSTTECH_API_TOKEN}",
+			data: `hosttech {
+				api_token {env.HOSTTECH_API_TOKEN}
+			}`,
+		},
+		"Token Set Twice Test": {
+			expectedError: true,
+			data: `hosttech my-token {
+				api_token other-token
+			}`,
+		},
+		"Missing Token Test": {
+			expectedError: true,
+			data:          `hosttech`,
+		},
+		"Unknown Subdirective Test": {
+			expectedError: true,
+			data: `hosttech {
+				api_key my-token
+			}`,
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			provider := Provider{new(hosttech.Provider)}
+			err := provider.UnmarshalCaddyfile(caddyfile.NewTestDispenser(testStruct.data))
+
+			if testStruct.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testStruct.expectedToken, provider.APIToken)
+		})
+	}
+}
+
+func TestProvider_Provision(t *testing.T) {
+	t.Setenv("HOSTTECH_API_TOKEN", "token-from-env")
+
+	module, err := caddy.GetModule("dns.providers.hosttech")
+	assert.NoError(t, err)
+
+	provider := module.New().(*Provider)
+	err = json.Unmarshal([]byte(`{"api_token": "{env.HOSTTECH_API_TOKEN}"}`), provider)
+	assert.NoError(t, err)
+
+	err = provider.Provision(caddy.Context{})
+	assert.NoError(t, err)
+	assert.Equal(t, "token-from-env", provider.APIToken)
+}
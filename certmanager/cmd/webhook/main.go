@@ -0,0 +1,19 @@
+// Command webhook runs the cert-manager DNS-01 webhook solver for Hosttech.ch.
+// The API group of the webhook is read from the environment variable GROUP_NAME.
+package main
+
+import (
+	"os"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd"
+	"github.com/libdns/hosttech/certmanager"
+)
+
+func main() {
+	groupName := os.Getenv("GROUP_NAME")
+	if groupName == "" {
+		panic("GROUP_NAME must be specified")
+	}
+
+	cmd.RunWebhookServer(groupName, &certmanager.Solver{})
+}
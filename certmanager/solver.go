@@ -0,0 +1,182 @@
+// Package certmanager implements a cert-manager ACME DNS-01 webhook solver for Hosttech.ch on top of the libdns
+// provider of github.com/libdns/hosttech.
+package certmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
+	acme "github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Resolvers should not cache the value of a previous challenge for the same name for long
+const challengeTTL = hosttech.MinimalTTL
+
+// Config is the solver configuration of an issuer, e.g.
+//
+//	webhook:
+//	  groupName: acme.example.com
+//	  solverName: hosttech
+//	  config:
+//	    apiTokenSecretRef:
+//	      name: hosttech-credentials
+//	      key: api-token
+type Config struct {
+	// APITokenSecretRef references the key of a Secret in the namespace of the challenge that holds the API token
+	APITokenSecretRef cmmeta.SecretKeySelector `json:"apiTokenSecretRef"`
+}
+
+// Solver solves DNS-01 challenges by creating TXT records with Hosttech.
+type Solver struct {
+	client kubernetes.Interface
+
+	// apiURL overrides the URL of the Hosttech API in tests. It is not part of the issuer config, because anyone who
+	// can write an issuer could send the API token of the referenced secret to their own server.
+	apiURL string
+}
+
+// Name is the name of the solver, to be used as solverName in the issuer.
+func (s *Solver) Name() string {
+	return "hosttech"
+}
+
+// Initialize creates the Kubernetes client used to read the API token secrets.
+func (s *Solver) Initialize(kubeClientConfig *rest.Config, stopCh <-chan struct{}) error {
+	client, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return err
+	}
+
+	s.client = client
+	return nil
+}
+
+// Present creates the challenge TXT record. cert-manager calls it again for a challenge that was already presented,
+// an existing record with the key is kept.
+func (s *Solver) Present(ch *acme.ChallengeRequest) error {
+	ctx := context.Background()
+
+	provider, err := s.provider(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	zone, name := zoneAndName(ch)
+	records, err := provider.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if len(challengeRecords(records, zone, name, ch.Key)) > 0 {
+		return nil
+	}
+
+	_, err = provider.AppendRecords(ctx, zone, []libdns.Record{
+		{
+			Type:  "TXT",
+			Name:  name,
+			Value: ch.Key,
+			TTL:   challengeTTL,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not create TXT record '%s': %w", ch.ResolvedFQDN, err)
+	}
+
+	return nil
+}
+
+// CleanUp deletes the challenge TXT records with the key of the challenge. Records of other challenges for the same
+// name are kept.
+func (s *Solver) CleanUp(ch *acme.ChallengeRequest) error {
+	ctx := context.Background()
+
+	provider, err := s.provider(ctx, ch)
+	if err != nil {
+		return err
+	}
+
+	zone, name := zoneAndName(ch)
+	records, err := provider.GetRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.DeleteRecords(ctx, zone, challengeRecords(records, zone, name, ch.Key))
+	if err != nil {
+		return fmt.Errorf("could not delete TXT record '%s': %w", ch.ResolvedFQDN, err)
+	}
+
+	return nil
+}
+
+// provider creates a provider with the API token from the secret referenced in the solver config.
+func (s *Solver) provider(ctx context.Context, ch *acme.ChallengeRequest) (*hosttech.Provider, error) {
+	config, err := loadConfig(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := config.APITokenSecretRef
+	if ref.Name == "" || ref.Key == "" {
+		return nil, fmt.Errorf("apiTokenSecretRef needs a name and a key")
+	}
+
+	secret, err := s.client.CoreV1().Secrets(ch.ResourceNamespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not read secret '%s/%s': %w", ch.ResourceNamespace, ref.Name, err)
+	}
+
+	token, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret '%s/%s' has no key '%s'", ch.ResourceNamespace, ref.Name, ref.Key)
+	}
+
+	return &hosttech.Provider{
+		APIToken: strings.TrimSpace(string(token)),
+		APIURL:   s.apiURL,
+	}, nil
+}
+
+func loadConfig(ch *acme.ChallengeRequest) (Config, error) {
+	config := Config{}
+	if ch.Config == nil {
+		return config, fmt.Errorf("the solver needs a config with apiTokenSecretRef")
+	}
+
+	err := json.Unmarshal(ch.Config.Raw, &config)
+	if err != nil {
+		return config, fmt.Errorf("could not decode solver config: %w", err)
+	}
+
+	return config, nil
+}
+
+// zoneAndName returns the zone of the challenge and the name of the challenge record relative to it.
+func zoneAndName(ch *acme.ChallengeRequest) (string, string) {
+	zone := strings.TrimSuffix(ch.ResolvedZone, ".")
+	fqdn := strings.TrimSuffix(ch.ResolvedFQDN, ".")
+	return zone, libdns.RelativeName(fqdn, zone)
+}
+
+// challengeRecords returns the TXT records of the name with the key, whatever the spelling of their name.
+func challengeRecords(records []libdns.Record, zone string, name string, key string) []libdns.Record {
+	var matching []libdns.Record
+	for _, record := range records {
+		if record.Type == "TXT" && hosttech.NormalizeName(record.Name, zone) == hosttech.NormalizeName(name, zone) && record.Value == key {
+			matching = append(matching, record)
+		}
+	}
+	return matching
+}
+
+// Interface guard
+var _ webhook.Solver = (*Solver)(nil)
@@ -0,0 +1,38 @@
+//go:build conformance
+
+package certmanager
+
+import (
+	"testing"
+	"time"
+
+	acmetest "github.com/cert-manager/cert-manager/test/acme"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRunsSuite runs the cert-manager conformance suite against the fake Hosttech API. It needs the envtest binaries
+// etcd and kube-apiserver on the PATH or in TEST_ASSET_ETCD and TEST_ASSET_KUBE_APISERVER:
+//
+//	go test -tags conformance ./...
+func TestRunsSuite(t *testing.T) {
+	server := hosttechtest.NewServer("token", "example.com")
+	defer server.Close()
+
+	dnsServer, err := server.StartDNS("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	fixture := acmetest.NewFixture(&Solver{apiURL: server.URL},
+		acmetest.SetResolvedZone("example.com."),
+		acmetest.SetAllowAmbientCredentials(false),
+		acmetest.SetManifestPath("testdata/hosttech"),
+		acmetest.SetDNSServer(dnsServer),
+		acmetest.SetUseAuthoritative(false),
+		acmetest.SetPollInterval(100*time.Millisecond),
+		acmetest.SetPropagationLimit(10*time.Second),
+		acmetest.SetConfig(map[string]any{
+			"apiTokenSecretRef": map[string]string{"name": "hosttech-credentials", "key": "api-token"},
+		}),
+	)
+	fixture.RunConformance(t)
+}
@@ -0,0 +1,59 @@
+package certmanager
+
+import (
+	"encoding/json"
+	"testing"
+
+	acme "github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSolver_PresentAndCleanUp(t *testing.T) {
+	server := hosttechtest.NewServer("token", "example.com")
+	defer server.Close()
+
+	solver := Solver{client: fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hosttech-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"api-token": []byte("token\n")},
+	})}
+	solver.apiURL = server.URL
+
+	config, err := json.Marshal(map[string]any{
+		"apiTokenSecretRef": map[string]string{"name": "hosttech-credentials", "key": "api-token"},
+	})
+	assert.NoError(t, err)
+
+	challenge := func(key string) *acme.ChallengeRequest {
+		return &acme.ChallengeRequest{
+			ResolvedFQDN:      "_acme-challenge.www.example.com.",
+			ResolvedZone:      "example.com.",
+			Key:               key,
+			ResourceNamespace: "default",
+			Config:            &apiextensionsv1.JSON{Raw: config},
+		}
+	}
+
+	assert.NoError(t, solver.Present(challenge("first")))
+	assert.NoError(t, solver.Present(challenge("second")))
+	assert.NoError(t, solver.Present(challenge("second")))
+	assert.Len(t, server.Records("example.com"), 2)
+	assert.NoError(t, solver.CleanUp(challenge("first")))
+
+	//Records are cleaned up whatever the spelling of their name
+	server.AddRecord("example.com", map[string]any{"type": "TXT", "name": "_ACME-Challenge.WWW", "text": "third", "ttl": 600})
+	assert.NoError(t, solver.CleanUp(challenge("third")))
+
+	records := server.Records("example.com")
+	assert.Len(t, records, 1)
+	assert.Equal(t, "_acme-challenge.www", records[0]["name"])
+	assert.Equal(t, "second", records[0]["text"])
+
+	wrongSecret := challenge("first")
+	wrongSecret.ResourceNamespace = "other"
+	assert.Error(t, solver.Present(wrongSecret))
+}
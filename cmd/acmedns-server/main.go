@@ -0,0 +1,53 @@
+// Command acmedns-server serves the acme-dns HTTP API and writes the challenge records to a Hosttech.ch zone.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN    the Hosttech API token (required)
+//	ZONE                  the zone the challenge records are written to (required)
+//	DOMAIN                the domain the accounts get their subdomains under, defaults to the zone
+//	REGISTRATIONS_FILE    path of the JSON file the accounts are persisted in (required)
+//	DISABLE_REGISTRATION  set to true to only accept updates of existing accounts
+//	LISTEN_ADDRESS        address of the API, defaults to :8080
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/acmedns"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	zone := os.Getenv("ZONE")
+	if zone == "" {
+		log.Fatal("ZONE is not set")
+	}
+
+	registrationsFile := os.Getenv("REGISTRATIONS_FILE")
+	if registrationsFile == "" {
+		log.Fatal("REGISTRATIONS_FILE is not set")
+	}
+
+	server := acmedns.Server{
+		Provider:            &hosttech.Provider{APIToken: apiToken},
+		Zone:                zone,
+		Domain:              os.Getenv("DOMAIN"),
+		RegistrationsFile:   registrationsFile,
+		DisableRegistration: os.Getenv("DISABLE_REGISTRATION") == "true",
+	}
+
+	address := os.Getenv("LISTEN_ADDRESS")
+	if address == "" {
+		address = ":8080"
+	}
+
+	log.Printf("serving the acme-dns API for zone %s on %s", zone, address)
+	log.Fatal(http.ListenAndServe(address, server.Handler()))
+}
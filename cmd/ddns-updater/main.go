@@ -0,0 +1,94 @@
+// Command ddns-updater keeps A and AAAA records in a Hosttech.ch zone pointed at the public address of the host.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN  the Hosttech API token (required)
+//	ZONE                the zone of the records (required)
+//	NAMES               comma separated names of the records relative to the zone, "@" for the apex (required)
+//	IPV4_DETECTOR       how the IPv4 address is detected, defaults to https://api.ipify.org
+//	IPV6_DETECTOR       how the IPv6 address is detected, defaults to none
+//	INTERVAL            time between two checks, defaults to 5m
+//	STATE_FILE          path of the file the last written addresses are persisted in
+//
+// Detectors are "https://<echo service>", "interface:<name>", "static:<address>" or "none".
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/ddns"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	zone := os.Getenv("ZONE")
+	if zone == "" {
+		log.Fatal("ZONE is not set")
+	}
+
+	var names []string
+	for _, name := range strings.Split(os.Getenv("NAMES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		log.Fatal("NAMES is not set")
+	}
+
+	ipv4, err := ddns.ParseDetector(getenv("IPV4_DETECTOR", "https://api.ipify.org"), false)
+	if err != nil {
+		log.Fatalf("invalid IPV4_DETECTOR: %s", err)
+	}
+
+	ipv6, err := ddns.ParseDetector(getenv("IPV6_DETECTOR", "none"), true)
+	if err != nil {
+		log.Fatalf("invalid IPV6_DETECTOR: %s", err)
+	}
+
+	if ipv4 == nil && ipv6 == nil {
+		log.Fatal("both IPV4_DETECTOR and IPV6_DETECTOR are disabled")
+	}
+
+	interval, err := time.ParseDuration(getenv("INTERVAL", "5m"))
+	if err != nil {
+		log.Fatalf("invalid INTERVAL: %s", err)
+	}
+
+	updater := ddns.Updater{
+		Provider:  &hosttech.Provider{APIToken: apiToken},
+		Zone:      zone,
+		Names:     names,
+		IPv4:      ipv4,
+		IPv6:      ipv6,
+		Interval:  interval,
+		StateFile: os.Getenv("STATE_FILE"),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("updating %s in zone %s every %s", strings.Join(names, ", "), zone, interval)
+	err = updater.Run(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
+
+func getenv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
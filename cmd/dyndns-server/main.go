@@ -0,0 +1,65 @@
+// Command dyndns-server serves the dyndns2 update protocol for hostnames in Hosttech.ch zones.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN  the Hosttech API token (required)
+//	HOSTS_FILE          path of a JSON file with the hostnames and their credentials (required)
+//	LISTEN_ADDRESS      address of the update endpoint, defaults to :8080
+//
+// The hosts file maps hostnames to their zone and credentials:
+//
+//	{"home.example.com": {"zone": "example.com", "username": "home", "password": "secret"}}
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/dyndns"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	hostsFile := os.Getenv("HOSTS_FILE")
+	if hostsFile == "" {
+		log.Fatal("HOSTS_FILE is not set")
+	}
+
+	content, err := os.ReadFile(hostsFile)
+	if err != nil {
+		log.Fatalf("could not read hosts file: %s", err)
+	}
+
+	hosts := map[string]dyndns.Host{}
+	err = json.Unmarshal(content, &hosts)
+	if err != nil {
+		log.Fatalf("could not parse hosts file: %s", err)
+	}
+
+	//Hostnames are looked up in lower case and without the trailing dot
+	normalizedHosts := map[string]dyndns.Host{}
+	for hostname, host := range hosts {
+		normalizedHosts[strings.ToLower(strings.TrimSuffix(hostname, "."))] = host
+	}
+
+	server := dyndns.Server{
+		Provider: &hosttech.Provider{APIToken: apiToken},
+		Hosts:    normalizedHosts,
+	}
+
+	address := os.Getenv("LISTEN_ADDRESS")
+	if address == "" {
+		address = ":8080"
+	}
+
+	log.Printf("serving dyndns2 updates for %d hostnames on %s", len(normalizedHosts), address)
+	log.Fatal(http.ListenAndServe(address, server.Handler()))
+}
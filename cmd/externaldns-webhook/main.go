@@ -0,0 +1,63 @@
+// Command externaldns-webhook runs an external-dns webhook provider for Hosttech.ch zones.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN  the Hosttech API token (required)
+//	DOMAIN_FILTER       comma separated list of the zones to manage (required)
+//	WEBHOOK_ADDRESS     address of the webhook API, defaults to localhost:8888
+//	HEALTH_ADDRESS      address of the /healthz endpoint, defaults to :8080
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/externaldns"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	var zones []string
+	for _, zone := range strings.Split(os.Getenv("DOMAIN_FILTER"), ",") {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+	if len(zones) == 0 {
+		log.Fatal("DOMAIN_FILTER is not set")
+	}
+
+	webhook := externaldns.Webhook{
+		Provider: &hosttech.Provider{
+			APIToken:  apiToken,
+			TTLPolicy: hosttech.TTLClamp,
+		},
+		Zones: zones,
+	}
+
+	health := http.NewServeMux()
+	health.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	go func() {
+		log.Fatal(http.ListenAndServe(getEnvOrDefault("HEALTH_ADDRESS", ":8080"), health))
+	}()
+
+	address := getEnvOrDefault("WEBHOOK_ADDRESS", "localhost:8888")
+	log.Printf("serving external-dns webhook for %s on %s", strings.Join(zones, ", "), address)
+	log.Fatal(http.ListenAndServe(address, webhook.Handler()))
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,79 @@
+// Command hosttech-drift compares Hosttech.ch zones with desired-state files, for use in CI.
+//
+//	hosttech-drift [-json] <desired state file>...
+//
+// The Hosttech API token is read from HOSTTECH_API_TOKEN. The command exits with 0 if all zones match their desired
+// state, with 1 if any zone drifted and with 2 if the check itself failed. With -json, the reports are printed as a
+// JSON array instead of the diff-like text format.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/libdns/hosttech"
+)
+
+const (
+	exitDrift = 1
+	exitError = 2
+)
+
+func main() {
+	printJSON := flag.Bool("json", false, "print the reports as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-json] <desired state file>...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		fail("HOSTTECH_API_TOKEN is not set")
+	}
+
+	provider := &hosttech.Provider{APIToken: apiToken}
+	reports := []hosttech.DriftReport{}
+	drifted := false
+	for _, path := range flag.Args() {
+		state, err := hosttech.ReadDesiredState(path)
+		if err != nil {
+			fail(err.Error())
+		}
+
+		report, err := provider.DetectDrift(context.Background(), state)
+		if err != nil {
+			fail(fmt.Sprintf("could not check zone '%s': %s", state.Zone, err))
+		}
+
+		reports = append(reports, report)
+		drifted = drifted || report.HasDrift()
+	}
+
+	if *printJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(reports)
+	} else {
+		for _, report := range reports {
+			fmt.Print(report)
+		}
+	}
+
+	if drifted {
+		os.Exit(exitDrift)
+	}
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(exitError)
+}
@@ -0,0 +1,60 @@
+// Command hosttech-exporter serves Prometheus metrics about the records of Hosttech.ch zones and the health of the
+// Hosttech API.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN  the Hosttech API token (required)
+//	ZONES               comma separated list of the zones to export, defaults to all zones of the account
+//	INTERVAL            time between two refreshes, defaults to 5m
+//	LISTEN_ADDRESS      address of the metrics endpoint, defaults to :9500
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/exporter"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	var zones []string
+	for _, zone := range strings.Split(os.Getenv("ZONES"), ",") {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+
+	interval := 5 * time.Minute
+	if value := os.Getenv("INTERVAL"); value != "" {
+		var err error
+		interval, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("invalid INTERVAL: %s", err)
+		}
+	}
+
+	e := &exporter.Exporter{
+		Provider: &hosttech.Provider{APIToken: apiToken},
+		Zones:    zones,
+		Interval: interval,
+	}
+	go e.Run(context.Background())
+
+	address := os.Getenv("LISTEN_ADDRESS")
+	if address == "" {
+		address = ":9500"
+	}
+
+	log.Printf("serving metrics on %s/metrics, refreshing every %s", address, interval)
+	log.Fatal(http.ListenAndServe(address, e.Handler()))
+}
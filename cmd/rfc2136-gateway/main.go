@@ -0,0 +1,92 @@
+// Command rfc2136-gateway runs a DNS server that applies TSIG-signed RFC 2136 dynamic updates to Hosttech.ch zones.
+//
+// It is configured with environment variables:
+//
+//	HOSTTECH_API_TOKEN  the Hosttech API token (required)
+//	ZONES               comma separated list of the zones that may be updated (required)
+//	TSIG_KEYS           comma separated list of TSIG keys as name:base64-secret (required). A key may be restricted
+//	                    to some of the zones as name:base64-secret:zone|zone, otherwise it may update all of them.
+//	LISTEN_ADDRESS      address to serve UDP and TCP on, defaults to :53
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/rfc2136"
+	"github.com/miekg/dns"
+)
+
+func main() {
+	apiToken := os.Getenv("HOSTTECH_API_TOKEN")
+	if apiToken == "" {
+		log.Fatal("HOSTTECH_API_TOKEN is not set")
+	}
+
+	zones := splitList(os.Getenv("ZONES"))
+	if len(zones) == 0 {
+		log.Fatal("ZONES is not set")
+	}
+
+	secrets := map[string]string{}
+	keyZones := map[string][]string{}
+	for _, key := range splitList(os.Getenv("TSIG_KEYS")) {
+		parts := strings.Split(key, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			log.Fatalf("TSIG key '%s' is not in the format name:base64-secret[:zone|zone]", key)
+		}
+		name := dns.Fqdn(parts[0])
+		secrets[name] = parts[1]
+
+		keyZones[name] = zones
+		if len(parts) == 3 {
+			keyZones[name] = strings.Split(parts[2], "|")
+		}
+	}
+	if len(secrets) == 0 {
+		log.Fatal("TSIG_KEYS is not set")
+	}
+
+	gateway := &rfc2136.Gateway{
+		Provider: &hosttech.Provider{APIToken: apiToken},
+		Zones:    zones,
+		KeyZones: keyZones,
+	}
+
+	address := getEnvOrDefault("LISTEN_ADDRESS", ":53")
+	log.Printf("accepting updates for %s on %s", strings.Join(zones, ", "), address)
+
+	errs := make(chan error)
+	for _, network := range []string{"udp", "tcp"} {
+		server := &dns.Server{
+			Addr:          address,
+			Net:           network,
+			Handler:       gateway,
+			TsigSecret:    secrets,
+			MsgAcceptFunc: rfc2136.AcceptUpdates,
+		}
+		go func() {
+			errs <- server.ListenAndServe()
+		}()
+	}
+	log.Fatal(<-errs)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvOrDefault(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,107 @@
+package hosttech
+
+import (
+	"context"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// CommentMode controls how the provider treats the comments of the records it writes.
+type CommentMode string
+
+const (
+	// CommentOverwrite writes a new comment on every create and update. This is the default.
+	CommentOverwrite CommentMode = ""
+	// CommentPreserve keeps the comment of existing records when they are updated. New records get a new comment.
+	CommentPreserve CommentMode = "preserve"
+	// CommentDisabled does not send any comment.
+	CommentDisabled CommentMode = "disabled"
+)
+
+// CommentData is passed to the comment template.
+type CommentData struct {
+	Zone   string
+	Record libdns.Record
+	// Time is the current time in UTC
+	Time time.Time
+}
+
+// RecordWithComment is a record together with the comment stored for it at Hosttech.
+type RecordWithComment struct {
+	libdns.Record
+	Comment string
+}
+
+// GetRecordsWithComments lists all the records in the zone, including the comment of each record.
+func (p *Provider) GetRecordsWithComments(ctx context.Context, zone string) (_ []RecordWithComment, err error) {
+	ctx, endSpan := p.startSpan(ctx, "GetRecordsWithComments", zone, nil)
+	defer endSpan(&err)
+
+	hosttechRecords, err := p.getCachedHosttechRecords(ctx, zone)
+	if err != nil {
+		return []RecordWithComment{}, err
+	}
+
+	var records []RecordWithComment
+	for _, record := range hosttechRecords {
+		records = append(records, newRecordWithComment(zone, record))
+	}
+
+	return records, nil
+}
+
+// getRecordComments returns the comments of all records in the zone by record ID.
+func (p *Provider) getRecordComments(ctx context.Context, zone string) (map[string]string, error) {
+	hosttechRecords, err := p.getHosttechRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := map[string]string{}
+	for _, record := range hosttechRecords {
+		comments[record.toLibdnsRecord(zone).ID] = record.value.comment()
+	}
+
+	return comments, nil
+}
+
+// recordComment returns the comment that is stored with a record the provider writes. The existing comments are only
+// consulted if comments are preserved. Ownership metadata always takes precedence over the comment mode.
+func (p *Provider) recordComment(zone string, record libdns.Record, existingComments map[string]string) (string, error) {
+	if p.Ownership != nil {
+		return p.Ownership.String(), nil
+	}
+
+	switch p.CommentMode {
+	case CommentDisabled:
+		return "", nil
+	case CommentPreserve:
+		if comment, ok := existingComments[record.ID]; ok && record.ID != "" {
+			return comment, nil
+		}
+	}
+
+	if p.CommentTemplate == "" {
+		return generateComment(), nil
+	}
+
+	tmpl, err := template.New("comment").Parse(p.CommentTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	err = tmpl.Execute(&sb, CommentData{
+		Zone:   zone,
+		Record: record,
+		Time:   time.Now().UTC(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
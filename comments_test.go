@@ -0,0 +1,56 @@
+package hosttech
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_RecordComment(t *testing.T) {
+	zone := "example.com"
+	record := libdns.Record{ID: "10", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second}
+	existingComments := map[string]string{"10": "written by an admin"}
+
+	input := map[string]struct {
+		expectedResult string
+		provider       *Provider
+		record         libdns.Record
+	}{
+		"Disabled Test": {
+			expectedResult: "",
+			provider:       &Provider{CommentMode: CommentDisabled},
+			record:         record,
+		},
+		"Preserve Existing Test": {
+			expectedResult: "written by an admin",
+			provider:       &Provider{CommentMode: CommentPreserve},
+			record:         record,
+		},
+		"Preserve New Record Test": {
+			expectedResult: "A www.example.com",
+			provider:       &Provider{CommentMode: CommentPreserve, CommentTemplate: "{{.Record.Type}} {{.Record.Name}}.{{.Zone}}"},
+			record:         libdns.Record{Type: "A", Name: "www", Value: "1.2.3.4"},
+		},
+		"Template Test": {
+			expectedResult: "www points to 1.2.3.4",
+			provider:       &Provider{CommentTemplate: "{{.Record.Name}} points to {{.Record.Value}}"},
+			record:         record,
+		},
+		"Ownership Test": {
+			expectedResult: "heritage=libdns,owner=team-a",
+			provider:       &Provider{CommentMode: CommentPreserve, Ownership: &Ownership{OwnerID: "team-a"}},
+			record:         record,
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			output, err := testStruct.provider.recordComment(zone, testStruct.record, existingComments)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testStruct.expectedResult, output)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// ConflictError is returned by SetRecords if conflicts are checked and a record changed at Hosttech since the
+// provider last returned it.
+type ConflictError struct {
+	Zone string
+
+	// Seen is the record as it was last returned by the provider
+	Seen RecordWithComment
+
+	// Current is the record as it is now, or nil if it was deleted
+	Current *RecordWithComment
+}
+
+func (c ConflictError) Error() string {
+	if c.Current == nil {
+		return fmt.Sprintf("record %s in zone '%s' was deleted since it was read", formatRecord(c.Seen.Record), c.Zone)
+	}
+	if c.Current.Record == c.Seen.Record {
+		return fmt.Sprintf("comment of record %s in zone '%s' was changed to '%s' since it was read", formatRecord(c.Seen.Record), c.Zone, c.Current.Comment)
+	}
+	return fmt.Sprintf("record %s in zone '%s' was changed to %s since it was read", formatRecord(c.Seen.Record), c.Zone, formatRecord(c.Current.Record))
+}
+
+// rememberRecords stores the records of the zone as the caller has seen them, if conflicts are checked. If the records
+// are the complete zone, they replace what was seen before, otherwise they are added to it. Only reads that return the
+// records to the caller may call it, internal reads would move the baseline of the conflict check.
+func (p *Provider) rememberRecords(zone string, hosttechRecords []HosttechRecordWrapper, complete bool) {
+	if !p.CheckConflicts {
+		return
+	}
+
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+
+	if p.seenRecords == nil {
+		p.seenRecords = map[string]map[string]RecordWithComment{}
+	}
+
+	seen := p.seenRecords[zoneKey(zone)]
+	if complete || seen == nil {
+		seen = map[string]RecordWithComment{}
+		p.seenRecords[zoneKey(zone)] = seen
+	}
+	for _, record := range hosttechRecords {
+		seen[record.toLibdnsRecord(zone).ID] = newRecordWithComment(zone, record)
+	}
+}
+
+// rememberWrite updates the seen state with a successful mutating API call, so that the writes of the provider itself
+// never conflict. Failed calls leave the seen state alone, because it is unknown whether the change reached the API.
+func (p *Provider) rememberWrite(zone string, httpMethod string, reqUrl string, response []byte, err error) {
+	if !p.CheckConflicts || p.DryRun || httpMethod == http.MethodGet || zone == "" || err != nil {
+		return
+	}
+
+	p.seenMu.Lock()
+	defer p.seenMu.Unlock()
+
+	seen := p.seenRecords[zoneKey(zone)]
+	if seen == nil {
+		return
+	}
+
+	if httpMethod == http.MethodDelete {
+		delete(seen, path.Base(reqUrl))
+		return
+	}
+
+	var parsedResponse = HosttechSingleResponseWrapper{}
+	if json.Unmarshal(response, &parsedResponse) != nil || parsedResponse.Data.value == nil {
+		return
+	}
+	written := newRecordWithComment(zone, parsedResponse.Data)
+	seen[written.ID] = written
+}
+
+// checkConflicts compares the records about to be updated with their current state at Hosttech. Records the provider
+// has never returned are not checked. The check narrows the window for lost updates, but the API offers no way to
+// make the update itself conditional.
+func (p *Provider) checkConflicts(ctx context.Context, zone string, recordIds []string) error {
+	if !p.CheckConflicts {
+		return nil
+	}
+
+	p.seenMu.Lock()
+	expected := map[string]RecordWithComment{}
+	for _, id := range recordIds {
+		if record, ok := p.seenRecords[zoneKey(zone)][id]; ok && id != "" {
+			expected[id] = record
+		}
+	}
+	p.seenMu.Unlock()
+
+	if len(expected) == 0 {
+		return nil
+	}
+
+	hosttechRecords, err := p.getHosttechRecords(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	current := map[string]RecordWithComment{}
+	for _, record := range hosttechRecords {
+		current[record.toLibdnsRecord(zone).ID] = newRecordWithComment(zone, record)
+	}
+
+	for _, id := range recordIds {
+		seen, ok := expected[id]
+		if !ok {
+			continue
+		}
+
+		record, exists := current[id]
+		if !exists {
+			return ConflictError{Zone: zone, Seen: seen}
+		}
+		if record != seen {
+			return ConflictError{Zone: zone, Seen: seen, Current: &record}
+		}
+	}
+
+	return nil
+}
+
+func newRecordWithComment(zone string, record HosttechRecordWrapper) RecordWithComment {
+	return RecordWithComment{
+		Record:  record.toLibdnsRecord(zone),
+		Comment: record.value.comment(),
+	}
+}
@@ -0,0 +1,171 @@
+package hosttech
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_CheckConflicts(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600, "comment": "web"})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CheckConflicts: true}
+	other := Provider{APIToken: "token", APIURL: api.URL, CommentMode: CommentPreserve}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	www, apiRecord := records[0], records[1]
+
+	//Consecutive updates of the provider itself do not conflict
+	www.Value = "1.2.3.6"
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{www})
+	assert.NoError(t, err)
+	www.TTL = 7200 * time.Second
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{www})
+	assert.NoError(t, err)
+
+	//A change made by someone else since the record was read is not overwritten
+	changed := apiRecord
+	changed.Value = "1.2.3.7"
+	_, err = other.SetRecords(ctx, "example.com", []libdns.Record{changed})
+	assert.NoError(t, err)
+
+	apiRecord.TTL = 7200 * time.Second
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{apiRecord})
+	var conflict ConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "1.2.3.5", conflict.Seen.Value)
+	assert.Equal(t, "1.2.3.7", conflict.Current.Value)
+	assert.Equal(t, "1.2.3.7", api.Records("example.com")[1]["ipv4"])
+
+	//Reading the record again resolves the conflict
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	apiRecord.Value = "1.2.3.7"
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{apiRecord})
+	assert.NoError(t, err)
+
+	//Records deleted since they were read are not recreated
+	_, err = other.DeleteRecords(ctx, "example.com", []libdns.Record{apiRecord})
+	assert.NoError(t, err)
+	_, err = provider.SetRecords(ctx, "example.com", []libdns.Record{apiRecord})
+	assert.True(t, errors.As(err, &conflict))
+	assert.Nil(t, conflict.Current)
+	assert.Len(t, api.Records("example.com"), 1)
+}
+
+func TestProvider_CheckConflictsComment(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600, "comment": "web"})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CheckConflicts: true}
+	records, err := provider.GetRecords(context.Background(), "example.com")
+	assert.NoError(t, err)
+
+	//Only the comment changed, e.g. in the Hosttech UI
+	other := Provider{APIToken: "token", APIURL: api.URL, CommentTemplate: "changed by hand"}
+	_, err = other.SetRecords(context.Background(), "example.com", records)
+	assert.NoError(t, err)
+
+	_, err = provider.SetRecords(context.Background(), "example.com", records)
+	assert.EqualError(t, err, "comment of record A www 1.2.3.4 ttl=1h0m0s in zone 'example.com' was changed to 'changed by hand' since it was read")
+	assert.Equal(t, "changed by hand", api.Records("example.com")[0]["comment"])
+}
+
+func TestProvider_CheckConflictsInternalReads(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL, CheckConflicts: true, CommentMode: CommentPreserve}
+	other := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+
+	changed := records[0]
+	changed.Value = "1.2.3.7"
+	_, err = other.SetRecords(ctx, "example.com", []libdns.Record{changed})
+	assert.NoError(t, err)
+
+	//Reads that do not hand the records to the caller as a base for updates keep the baseline
+	_, err = provider.GetRecordsWithComments(ctx, "example.com")
+	assert.NoError(t, err)
+	_, err = provider.Snapshot(ctx, "example.com")
+	assert.NoError(t, err)
+
+	records[0].TTL = 7200 * time.Second
+	_, err = provider.SetRecords(ctx, "example.com", records)
+	assert.ErrorAs(t, err, &ConflictError{})
+	assert.Equal(t, "1.2.3.7", api.Records("example.com")[0]["ipv4"])
+}
+
+func TestProvider_CheckConflictsWithJournal(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+
+	journal := &Journal{Path: filepath.Join(t.TempDir(), "journal.jsonl")}
+	provider := Provider{APIToken: "token", APIURL: api.URL, CheckConflicts: true, Journal: journal}
+	other := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+
+	changed := records[0]
+	changed.Value = "1.2.3.7"
+	_, err = other.SetRecords(ctx, "example.com", []libdns.Record{changed})
+	assert.NoError(t, err)
+
+	//Reading the state before the change for the journal must not hide the conflict
+	records[0].TTL = 7200 * time.Second
+	_, err = provider.SetRecords(ctx, "example.com", records)
+	assert.ErrorAs(t, err, &ConflictError{})
+	assert.Equal(t, "1.2.3.7", api.Records("example.com")[0]["ipv4"])
+
+	entries, err := journal.Entries()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestProvider_CheckConflictsUndo(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	canonicalZonePaths(api)
+
+	journal := &Journal{Path: filepath.Join(t.TempDir(), "journal.jsonl")}
+	provider := Provider{APIToken: "token", APIURL: api.URL, CheckConflicts: true, Journal: journal}
+	other := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	//The baseline is shared by all spellings of the zone
+	records, err := provider.GetRecords(ctx, "example.com.")
+	assert.NoError(t, err)
+	records[0].Value = "1.2.3.5"
+	_, err = provider.SetRecords(ctx, "example.com", records)
+	assert.NoError(t, err)
+
+	changed := records[0]
+	changed.Value = "1.2.3.7"
+	_, err = other.SetRecords(ctx, "example.com", []libdns.Record{changed})
+	assert.NoError(t, err)
+
+	//Undo does not overwrite a change made by someone else since the provider wrote the record
+	_, err = provider.Undo(ctx)
+	assert.ErrorAs(t, err, &ConflictError{})
+	assert.Equal(t, "1.2.3.7", api.Records("example.com")[0]["ipv4"])
+}
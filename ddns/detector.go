@@ -0,0 +1,170 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Detector detects the current public address of one address family.
+type Detector interface {
+	Detect(ctx context.Context) (netip.Addr, error)
+}
+
+// InterfaceDetector uses the first global unicast address of a network interface, for hosts that have a public
+// address assigned directly.
+type InterfaceDetector struct {
+	// Interface is the name of the interface, e.g. eth0
+	Interface string
+
+	// IPv6 selects IPv6 instead of IPv4 addresses
+	IPv6 bool
+}
+
+// Detect implements Detector.
+func (d InterfaceDetector) Detect(ctx context.Context) (netip.Addr, error) {
+	iface, err := net.InterfaceByName(d.Interface)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	addresses, err := iface.Addrs()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	for _, address := range addresses {
+		prefix, err := netip.ParsePrefix(address.String())
+		if err != nil {
+			continue
+		}
+
+		ip := prefix.Addr().Unmap()
+		if ip.Is6() == d.IPv6 && ip.IsGlobalUnicast() && !ip.IsPrivate() {
+			return ip, nil
+		}
+	}
+
+	return netip.Addr{}, fmt.Errorf("interface %s has no public %s address", d.Interface, familyName(d.IPv6))
+}
+
+// HTTPDetector asks an echo service like https://api.ipify.org, that responds with the address of the client as
+// plain text.
+type HTTPDetector struct {
+	URL string
+
+	// IPv6 selects IPv6 instead of IPv4. The request is sent over the selected family.
+	IPv6 bool
+
+	// Client overrides the HTTP client. It has to connect over the selected family itself.
+	Client *http.Client
+}
+
+// Detect implements Detector.
+func (d HTTPDetector) Detect(ctx context.Context) (netip.Addr, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("%s responded with status %d", d.URL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	ip, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%s did not respond with an address: %w", d.URL, err)
+	}
+
+	ip = ip.Unmap()
+	if ip.Is6() != d.IPv6 {
+		return netip.Addr{}, fmt.Errorf("%s responded with %s instead of an %s address", d.URL, ip, familyName(d.IPv6))
+	}
+
+	return ip, nil
+}
+
+func (d HTTPDetector) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+
+	network := "tcp4"
+	if d.IPv6 {
+		network = "tcp6"
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _ string, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}
+}
+
+// StaticDetector always returns the same address. It stands in for a real detector in tests and setups where the
+// address is known, e.g. behind a NAT with a fixed address.
+type StaticDetector struct {
+	Address netip.Addr
+}
+
+// Detect implements Detector.
+func (d StaticDetector) Detect(ctx context.Context) (netip.Addr, error) {
+	if !d.Address.IsValid() {
+		return netip.Addr{}, fmt.Errorf("no address configured")
+	}
+	return d.Address, nil
+}
+
+// ParseDetector creates a detector from a specification as used in configuration files and environment variables:
+//
+//	https://<echo service>  HTTPDetector
+//	interface:<name>        InterfaceDetector
+//	static:<address>        StaticDetector
+//	none                    no detector, the address family is not updated
+func ParseDetector(spec string, ipv6 bool) (Detector, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "http", "https":
+		return HTTPDetector{URL: spec, IPv6: ipv6}, nil
+	case "interface":
+		return InterfaceDetector{Interface: value, IPv6: ipv6}, nil
+	case "static":
+		address, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		if address.Is6() != ipv6 {
+			return nil, fmt.Errorf("'%s' is not an %s address", value, familyName(ipv6))
+		}
+		return StaticDetector{Address: address}, nil
+	default:
+		return nil, fmt.Errorf("unknown detector '%s'", spec)
+	}
+}
+
+func familyName(ipv6 bool) string {
+	if ipv6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
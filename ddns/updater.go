@@ -0,0 +1,334 @@
+// Package ddns keeps A and AAAA records of Hosttech.ch zones pointed at the current public address of a host, for
+// home-lab and edge hosts without a static address.
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/internal/atomicfile"
+	"github.com/libdns/libdns"
+)
+
+// Provider reads the zone to find the records of the names, and creates or updates them.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+}
+
+// State is what the updater remembers between runs, the addresses that were last written to the zone and the names
+// they were written to.
+type State struct {
+	IPv4      string    `json:"ipv4,omitempty"`
+	IPv6      string    `json:"ipv6,omitempty"`
+	Names     []string  `json:"names,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+}
+
+// Updater periodically detects the public addresses of the host and updates the records when they changed.
+type Updater struct {
+	Provider Provider
+
+	// Zone is the zone of the records
+	Zone string
+
+	// Names are the names of the records relative to the zone, "" or "@" for the apex
+	Names []string
+
+	// IPv4 and IPv6 detect the addresses of the A and AAAA records. A nil detector disables the record type.
+	IPv4 Detector
+	IPv6 Detector
+
+	// TTL of the records. Defaults to hosttech.MinimalTTL, so resolvers pick up a new address soon.
+	TTL time.Duration
+
+	// Interval between two checks. Defaults to 5 minutes.
+	Interval time.Duration
+
+	// MaxBackoff limits the delay between retries after failures, which doubles with every failure starting at
+	// 30 seconds. Defaults to 1 hour.
+	MaxBackoff time.Duration
+
+	// StateFile is where the state is persisted between restarts. Without it, the records are checked against the
+	// zone after every start.
+	StateFile string
+
+	// Logger receives a line for every written record and every failed check, along with the delay before the next
+	// attempt. If it is nil, log.Default() is used.
+	Logger *log.Logger
+
+	state *State
+}
+
+// Run checks the addresses until the context is canceled.
+func (u *Updater) Run(ctx context.Context) error {
+	failures := 0
+	for {
+		delay := u.interval()
+		err := u.Update(ctx)
+		if err != nil {
+			failures++
+			delay = backoff(failures, u.maxBackoff())
+			u.logger().Printf("update failed, retrying in %s: %s", delay, err)
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Update detects the addresses once and updates the records of the families whose address changed since the last
+// successful update. The zone is only contacted when an address or the configured names changed.
+func (u *Updater) Update(ctx context.Context) error {
+	state, err := u.loadState()
+	if err != nil {
+		return err
+	}
+
+	names := u.names()
+	namesChanged := !slices.Equal(names, state.Names)
+
+	var errs []error
+	var changedRecords []libdns.Record
+	newState := *state
+	for _, family := range []struct {
+		recordType string
+		detector   Detector
+		current    *string
+	}{
+		{"A", u.IPv4, &newState.IPv4},
+		{"AAAA", u.IPv6, &newState.IPv6},
+	} {
+		if family.detector == nil {
+			continue
+		}
+
+		address, err := family.detector.Detect(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not detect address for %s records: %w", family.recordType, err))
+			continue
+		}
+
+		if address.String() == *family.current && !namesChanged {
+			continue
+		}
+
+		records, err := u.records(ctx, family.recordType, address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		changedRecords = append(changedRecords, records...)
+		*family.current = address.String()
+	}
+
+	if len(changedRecords) > 0 {
+		err = u.writeRecords(ctx, changedRecords)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		for _, record := range changedRecords {
+			u.logger().Printf("updated %s record '%s' to %s", record.Type, libdns.AbsoluteName(record.Name, u.Zone), record.Value)
+		}
+	}
+
+	//The names are only remembered once all families were written to them, so failed families are retried
+	if len(errs) == 0 {
+		newState.Names = names
+	}
+
+	if newState.IPv4 != state.IPv4 || newState.IPv6 != state.IPv6 || !slices.Equal(newState.Names, state.Names) {
+		newState.UpdatedAt = time.Now()
+		err = u.saveState(newState)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// writeRecords creates the records without an ID and updates the others.
+func (u *Updater) writeRecords(ctx context.Context, records []libdns.Record) error {
+	var newRecords, existingRecords []libdns.Record
+	for _, record := range records {
+		if record.ID == "" {
+			newRecords = append(newRecords, record)
+		} else {
+			existingRecords = append(existingRecords, record)
+		}
+	}
+
+	if len(newRecords) > 0 {
+		_, err := u.Provider.AppendRecords(ctx, u.Zone, newRecords)
+		if err != nil {
+			return fmt.Errorf("could not create records: %w", err)
+		}
+	}
+	if len(existingRecords) > 0 {
+		_, err := u.Provider.SetRecords(ctx, u.Zone, existingRecords)
+		if err != nil {
+			return fmt.Errorf("could not update records: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// records returns the records of the type for all names with the address, reusing the IDs of existing records.
+func (u *Updater) records(ctx context.Context, recordType string, address netip.Addr) ([]libdns.Record, error) {
+	existing, err := u.Provider.GetRecords(ctx, u.Zone)
+	if err != nil {
+		return nil, fmt.Errorf("could not get records of zone '%s': %w", u.Zone, err)
+	}
+
+	var records []libdns.Record
+	for _, name := range u.names() {
+		record := libdns.Record{}
+		for _, existingRecord := range existing {
+			if existingRecord.Type == recordType && strings.EqualFold(existingRecord.Name, name) {
+				record = existingRecord
+				break
+			}
+		}
+
+		//Records that already have the address, e.g. updated by another host, are left alone
+		if record.Value == address.String() {
+			continue
+		}
+
+		record.Type = recordType
+		record.Name = name
+		record.Value = address.String()
+		record.TTL = u.ttl()
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// names returns the configured names in the form they are remembered in the state, sorted and without duplicates.
+func (u *Updater) names() []string {
+	var names []string
+	for _, name := range u.Names {
+		if name == "@" {
+			name = ""
+		}
+		names = append(names, strings.ToLower(name))
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// State returns the state of the last successful update.
+func (u *Updater) State() (State, error) {
+	state, err := u.loadState()
+	if err != nil {
+		return State{}, err
+	}
+	return *state, nil
+}
+
+func (u *Updater) loadState() (*State, error) {
+	if u.state != nil {
+		return u.state, nil
+	}
+
+	u.state = &State{}
+	if u.StateFile == "" {
+		return u.state, nil
+	}
+
+	content, err := os.ReadFile(u.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return u.state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read state: %w", err)
+	}
+
+	err = json.Unmarshal(content, u.state)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse state file '%s': %w", u.StateFile, err)
+	}
+
+	return u.state, nil
+}
+
+// saveState writes the state to the state file, replacing it atomically.
+func (u *Updater) saveState(state State) error {
+	*u.state = state
+	if u.StateFile == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(u.StateFile, content, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not write state: %w", err)
+	}
+
+	return nil
+}
+
+// backoff returns the delay after the number of consecutive failures.
+func backoff(failures int, maxBackoff time.Duration) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+func (u *Updater) ttl() time.Duration {
+	if u.TTL == 0 {
+		return hosttech.MinimalTTL
+	}
+	return u.TTL
+}
+
+func (u *Updater) interval() time.Duration {
+	if u.Interval == 0 {
+		return 5 * time.Minute
+	}
+	return u.Interval
+}
+
+func (u *Updater) maxBackoff() time.Duration {
+	if u.MaxBackoff == 0 {
+		return time.Hour
+	}
+	return u.MaxBackoff
+}
+
+func (u *Updater) logger() *log.Logger {
+	if u.Logger != nil {
+		return u.Logger
+	}
+	return log.Default()
+}
+
+// Interface guard
+var _ Provider = (*hosttech.Provider)(nil)
@@ -0,0 +1,163 @@
+package ddns
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider counts the calls to the zone and the created records
+type countingProvider struct {
+	Provider
+	calls    int
+	appended int
+}
+
+func (c *countingProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	c.calls++
+	return c.Provider.GetRecords(ctx, zone)
+}
+
+func (c *countingProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	c.appended += len(records)
+	return c.Provider.AppendRecords(ctx, zone, records)
+}
+
+func TestUpdater_Update(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "home", "ipv4": "1.2.3.4", "ttl": 600})
+
+	provider := &countingProvider{Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL}}
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	newUpdater := func(ipv4 string) *Updater {
+		return &Updater{
+			Provider:  provider,
+			Zone:      "example.com",
+			Names:     []string{"home", "@"},
+			IPv4:      StaticDetector{Address: netip.MustParseAddr(ipv4)},
+			IPv6:      StaticDetector{Address: netip.MustParseAddr("2001:db8::1")},
+			StateFile: stateFile,
+			Logger:    log.New(io.Discard, "", 0),
+		}
+	}
+
+	updater := newUpdater("1.2.3.5")
+	assert.NoError(t, updater.Update(context.Background()))
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 4)
+	assert.Equal(t, "home", records[0]["name"])
+	assert.Equal(t, "1.2.3.5", records[0]["ipv4"])
+	assert.Equal(t, 3, provider.appended)
+
+	//Unchanged addresses don't contact the zone, even after a restart
+	calls := provider.calls
+	assert.NoError(t, updater.Update(context.Background()))
+	assert.NoError(t, newUpdater("1.2.3.5").Update(context.Background()))
+	assert.Equal(t, calls, provider.calls)
+
+	restarted := newUpdater("1.2.3.6")
+	assert.NoError(t, restarted.Update(context.Background()))
+	assert.Len(t, api.Records("example.com"), 4)
+	assert.Equal(t, "1.2.3.6", api.Records("example.com")[0]["ipv4"])
+
+	state, err := restarted.State()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.6", state.IPv4)
+	assert.Equal(t, "2001:db8::1", state.IPv6)
+	assert.Equal(t, []string{"", "home"}, state.Names)
+
+	//Names added to the config are created without an address change
+	added := newUpdater("1.2.3.6")
+	added.Names = append(added.Names, "vpn")
+	assert.NoError(t, added.Update(context.Background()))
+	records = api.Records("example.com")
+	assert.Len(t, records, 6)
+	assert.Equal(t, "vpn", records[4]["name"])
+	assert.Equal(t, "1.2.3.6", records[4]["ipv4"])
+	assert.Equal(t, 5, provider.appended)
+
+	calls = provider.calls
+	assert.NoError(t, added.Update(context.Background()))
+	assert.Equal(t, calls, provider.calls)
+}
+
+func TestHTTPDetector_Detect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer server.Close()
+
+	address, err := HTTPDetector{URL: server.URL}.Detect(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddr("203.0.113.7"), address)
+
+	_, err = HTTPDetector{URL: server.URL, IPv6: true, Client: server.Client()}.Detect(context.Background())
+	assert.Error(t, err)
+}
+
+func TestParseDetector(t *testing.T) {
+	input := map[string]struct {
+		spec          string
+		ipv6          bool
+		expected      Detector
+		expectedError bool
+	}{
+		"None Test": {
+			spec:     "none",
+			expected: nil,
+		},
+		"HTTP Test": {
+			spec:     "https://api.ipify.org",
+			expected: HTTPDetector{URL: "https://api.ipify.org"},
+		},
+		"Interface Test": {
+			spec:     "interface:eth0",
+			ipv6:     true,
+			expected: InterfaceDetector{Interface: "eth0", IPv6: true},
+		},
+		"Static Test": {
+			spec:     "static:1.2.3.4",
+			expected: StaticDetector{Address: netip.MustParseAddr("1.2.3.4")},
+		},
+		"Static Wrong Family Test": {
+			spec:          "static:1.2.3.4",
+			ipv6:          true,
+			expectedError: true,
+		},
+		"Unknown Detector Test": {
+			spec:          "upnp",
+			expectedError: true,
+		},
+	}
+
+	for desc, tc := range input {
+		t.Run(desc, func(t *testing.T) {
+			detector, err := ParseDetector(tc.spec, tc.ipv6)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, detector)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1, time.Hour))
+	assert.Equal(t, 2*time.Minute, backoff(3, time.Hour))
+	assert.Equal(t, time.Hour, backoff(20, time.Hour))
+}
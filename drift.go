@@ -0,0 +1,157 @@
+package hosttech
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"gopkg.in/yaml.v3"
+)
+
+// DesiredState is the state of a zone as it is kept in version control.
+type DesiredState struct {
+	Zone    string          `json:"zone" yaml:"zone"`
+	Records []DesiredRecord `json:"records" yaml:"records"`
+}
+
+// DesiredRecord is a record of the desired state. A TTL of zero matches any TTL.
+type DesiredRecord struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Value    string `json:"value" yaml:"value"`
+	TTL      int    `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Priority uint   `json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// Record converts the desired record to a libdns record.
+func (d DesiredRecord) Record() libdns.Record {
+	return libdns.Record{
+		Type:     strings.ToUpper(d.Type),
+		Name:     d.Name,
+		Value:    d.Value,
+		TTL:      time.Duration(d.TTL) * time.Second,
+		Priority: d.Priority,
+	}
+}
+
+// ReadDesiredState reads a desired state from a YAML or JSON file, e.g.
+//
+//	zone: example.com
+//	records:
+//	  - {name: www, type: A, value: 1.2.3.4, ttl: 3600}
+//	  - {name: "@", type: MX, value: mail.example.com, priority: 10}
+//
+// Unknown keys are rejected, so a typo does not silently drop a field.
+func ReadDesiredState(path string) (DesiredState, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DesiredState{}, err
+	}
+
+	//YAML is a superset of JSON, so one decoder handles both
+	state := DesiredState{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&state)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return DesiredState{}, fmt.Errorf("could not parse desired state '%s': %w", path, err)
+	}
+
+	if state.Zone == "" {
+		return DesiredState{}, fmt.Errorf("desired state '%s' has no zone", path)
+	}
+
+	return state, nil
+}
+
+// DriftReport lists the differences between the records in the zone and the desired state.
+type DriftReport struct {
+	Zone string `json:"zone"`
+
+	// Added are records in the zone that are not part of the desired state
+	Added []SnapshotRecord `json:"added"`
+
+	// Removed are records of the desired state that are missing in the zone
+	Removed []SnapshotRecord `json:"removed"`
+
+	// Changed are records whose value, TTL or priority differs from the desired state
+	Changed []DriftChange `json:"changed"`
+}
+
+// DriftChange is a record that exists in the zone and in the desired state, but differs.
+type DriftChange struct {
+	Live    SnapshotRecord `json:"live"`
+	Desired SnapshotRecord `json:"desired"`
+}
+
+// HasDrift reports whether the zone differs from the desired state.
+func (d DriftReport) HasDrift() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Changed) > 0
+}
+
+// String renders the report with one difference per line, in the same format as Plan.
+func (d DriftReport) String() string {
+	if !d.HasDrift() {
+		return fmt.Sprintf("zone %s matches the desired state\n", d.Zone)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "zone %s: %d added, %d removed, %d changed\n", d.Zone, len(d.Added), len(d.Removed), len(d.Changed))
+	for _, record := range d.Added {
+		fmt.Fprintf(&sb, "+ %s\n", formatRecord(record.Record()))
+	}
+	for _, record := range d.Removed {
+		fmt.Fprintf(&sb, "- %s\n", formatRecord(record.Record()))
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(&sb, "~ %s -> %s\n", formatRecord(change.Live.Record()), formatRecord(change.Desired.Record()))
+	}
+
+	return sb.String()
+}
+
+// DetectDrift compares the records in the zone with the desired state. Records are matched the same way as in Plan,
+// so the report is the inverse of the plan that would restore the desired state: records added in the zone would be
+// deleted, removed records would be created and changed records would be updated.
+func (p *Provider) DetectDrift(ctx context.Context, state DesiredState) (_ DriftReport, err error) {
+	ctx, endSpan := p.startSpan(ctx, "DetectDrift", state.Zone, nil)
+	defer endSpan(&err)
+
+	var desired []libdns.Record
+	for _, record := range state.Records {
+		desired = append(desired, record.Record())
+	}
+
+	plan, err := p.Plan(ctx, state.Zone, desired)
+	if err != nil {
+		return DriftReport{}, err
+	}
+
+	report := DriftReport{
+		Zone:    state.Zone,
+		Added:   []SnapshotRecord{},
+		Removed: []SnapshotRecord{},
+		Changed: []DriftChange{},
+	}
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case ChangeDelete:
+			report.Added = append(report.Added, newSnapshotRecord(change.Current, ""))
+		case ChangeCreate:
+			report.Removed = append(report.Removed, newSnapshotRecord(change.Desired, ""))
+		case ChangeUpdate:
+			report.Changed = append(report.Changed, DriftChange{
+				Live:    newSnapshotRecord(change.Current, ""),
+				Desired: newSnapshotRecord(change.Desired, ""),
+			})
+		}
+	}
+
+	return report, nil
+}
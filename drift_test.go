@@ -0,0 +1,60 @@
+package hosttech
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_DetectDrift(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.9", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "ui-edit", "text": "added by hand", "ttl": 3600})
+
+	path := filepath.Join(t.TempDir(), "example.com.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+zone: example.com
+records:
+  - {name: www, type: A, value: 1.2.3.4, ttl: 3600}
+  - {name: api, type: A, value: 1.2.3.5}
+  - {name: "@", type: MX, value: mail.example.com, priority: 10, ttl: 3600}
+`), 0o600))
+
+	state, err := ReadDesiredState(path)
+	assert.NoError(t, err)
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	report, err := provider.DetectDrift(context.Background(), state)
+	assert.NoError(t, err)
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, "ui-edit", report.Added[0].Name)
+	assert.Equal(t, "MX", report.Removed[0].Type)
+	assert.Equal(t, "1.2.3.9", report.Changed[0].Live.Value)
+	assert.Equal(t, "1.2.3.5", report.Changed[0].Desired.Value)
+	assert.Equal(t, `zone example.com: 1 added, 1 removed, 1 changed
++ TXT ui-edit added by hand ttl=1h0m0s
+- MX @ mail.example.com ttl=1h0m0s priority=10
+~ A api 1.2.3.9 ttl=1h0m0s -> A api 1.2.3.5 ttl=0s
+`, report.String())
+}
+
+func TestReadDesiredState(t *testing.T) {
+	dir := t.TempDir()
+
+	json := filepath.Join(dir, "state.json")
+	assert.NoError(t, os.WriteFile(json, []byte(`{"zone": "example.com", "records": [{"name": "www", "type": "a", "value": "1.2.3.4"}]}`), 0o600))
+	state, err := ReadDesiredState(json)
+	assert.NoError(t, err)
+	assert.Equal(t, "A", state.Records[0].Record().Type)
+
+	typo := filepath.Join(dir, "typo.yaml")
+	assert.NoError(t, os.WriteFile(typo, []byte("zone: example.com\nrecords:\n  - {name: www, type: A, vaule: 1.2.3.4}\n"), 0o600))
+	_, err = ReadDesiredState(typo)
+	assert.Error(t, err)
+}
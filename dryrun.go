@@ -0,0 +1,119 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+)
+
+// Operation is a mutating API request that was recorded instead of being sent, because the provider runs in dry-run mode.
+type Operation struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+func (o Operation) String() string {
+	if o.Body == "" {
+		return fmt.Sprintf("%s %s", o.Method, o.URL)
+	}
+	return fmt.Sprintf("%s %s %s", o.Method, o.URL, o.Body)
+}
+
+// DryRunOperations returns all operations that were recorded in dry-run mode, in the order they would have been sent.
+func (p *Provider) DryRunOperations() []Operation {
+	p.dryRunMu.Lock()
+	defer p.dryRunMu.Unlock()
+
+	return append([]Operation{}, p.dryRunOperations...)
+}
+
+// ResetDryRunOperations discards all operations recorded so far.
+func (p *Provider) ResetDryRunOperations() {
+	p.dryRunMu.Lock()
+	defer p.dryRunMu.Unlock()
+
+	p.dryRunOperations = nil
+}
+
+// dryRunApiCall records and logs a mutating request instead of sending it. The response mimics what the API would
+// return, so the calling methods can continue as usual. Updates and deletions of records that do not exist fail with
+// the same 404 the API would return.
+func (p *Provider) dryRunApiCall(ctx context.Context, httpMethod string, reqUrl string, body io.Reader, zone string) ([]byte, error) {
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		bodyBytes, err = io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var recordId string
+	if httpMethod == http.MethodPut || httpMethod == http.MethodDelete {
+		recordId = path.Base(reqUrl)
+		exists, err := p.recordExists(ctx, zone, recordId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, ApiError{
+				s:         fmt.Sprintf("call to API was not successful, returned the status code '%d %s'", http.StatusNotFound, http.StatusText(http.StatusNotFound)),
+				ErrorCode: http.StatusNotFound,
+			}
+		}
+	}
+
+	operation := Operation{
+		Method: httpMethod,
+		URL:    reqUrl,
+		Body:   string(bodyBytes),
+	}
+
+	p.dryRunMu.Lock()
+	p.dryRunOperations = append(p.dryRunOperations, operation)
+	p.dryRunMu.Unlock()
+
+	logger := p.DryRunLogger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("dry-run: %s", operation)
+
+	if bodyBytes == nil {
+		return nil, nil
+	}
+
+	//Echo the body back like the API does, including the id of an updated record
+	var data map[string]any
+	err := json.Unmarshal(bodyBytes, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if recordId != "" {
+		data["id"] = json.Number(recordId)
+	}
+
+	return json.Marshal(map[string]any{"data": data})
+}
+
+func (p *Provider) recordExists(ctx context.Context, zone string, recordId string) (bool, error) {
+	hosttechRecords, err := p.getHosttechRecords(ctx, zone)
+	if err != nil {
+		return false, err
+	}
+
+	for _, record := range hosttechRecords {
+		if record.toLibdnsRecord(zone).ID == recordId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
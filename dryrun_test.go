@@ -0,0 +1,93 @@
+package hosttech
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_DryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s request in dry-run mode", r.Method)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"data": [{ "id": 10, "type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600, "comment": "my first record" }]}`))
+	}))
+	defer server.Close()
+
+	provider := Provider{
+		APIToken:     "token",
+		DryRun:       true,
+		DryRunLogger: log.New(io.Discard, "", 0),
+		APIURL:       server.URL,
+	}
+	zone := "example.com"
+
+	updatedRecords, err := provider.SetRecords(context.Background(), zone, []libdns.Record{
+		{ID: "10", Type: "A", Name: "www", Value: "1.2.3.5", TTL: 3600 * time.Second},
+		{ID: "11", Type: "A", Name: "api", Value: "1.2.3.6", TTL: 3600 * time.Second},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10", "0"}, []string{updatedRecords[0].ID, updatedRecords[1].ID})
+	assert.Equal(t, "1.2.3.5", updatedRecords[0].Value)
+
+	_, err = provider.DeleteRecords(context.Background(), zone, []libdns.Record{{ID: "10"}})
+	assert.NoError(t, err)
+
+	operations := provider.DryRunOperations()
+	assert.Len(t, operations, 3)
+	assert.Equal(t, http.MethodPut, operations[0].Method)
+	assert.Equal(t, server.URL+"/zones/example.com/records/10", operations[0].URL)
+	assert.Contains(t, operations[0].Body, `"ipv4":"1.2.3.5"`)
+	assert.Equal(t, http.MethodPost, operations[1].Method)
+	assert.Equal(t, server.URL+"/zones/example.com/records", operations[1].URL)
+	assert.Equal(t, Operation{Method: http.MethodDelete, URL: server.URL + "/zones/example.com/records/10"}, operations[2])
+}
+
+func TestProvider_DryRunReads(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	ownership := &Ownership{OwnerID: "team-a", Enforce: true}
+	for _, name := range []string{"www", "api", "shop"} {
+		api.AddRecord("example.com", map[string]any{"type": "A", "name": name, "ipv4": "1.2.3.4", "ttl": 3600, "comment": ownership.String()})
+	}
+	reads := countReads(api)
+
+	provider := Provider{
+		APIToken:       "token",
+		APIURL:         api.URL,
+		DryRun:         true,
+		DryRunLogger:   log.New(io.Discard, "", 0),
+		Ownership:      ownership,
+		CheckConflicts: true,
+	}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	for i := range records {
+		records[i].Value = "1.2.3.5"
+	}
+
+	//The ownership and conflict checks and the existence check of every update share a single read of the zone
+	reads.Store(0)
+	_, err = provider.SetRecords(ctx, "example.com", records)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, reads.Load())
+
+	reads.Store(0)
+	_, err = provider.DeleteRecords(ctx, "example.com", records)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, reads.Load())
+	assert.Len(t, provider.DryRunOperations(), 6)
+}
@@ -0,0 +1,279 @@
+// Package dyndns implements the dyndns2 update protocol (/nic/update) on top of the Hosttech.ch libdns provider,
+// for routers and appliances that can only update their address that way.
+package dyndns
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+)
+
+// Provider looks up the address records of the hostnames and creates or updates them.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+}
+
+// Host is a hostname that may be updated through the server.
+type Host struct {
+	// Zone is the zone the hostname belongs to
+	Zone string `json:"zone"`
+
+	// Username and Password are the credentials the client has to authenticate with to update the hostname
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Server serves the dyndns2 update endpoint.
+type Server struct {
+	Provider Provider
+
+	// Hosts maps the fully qualified hostnames that may be updated to their zone and credentials
+	Hosts map[string]Host
+
+	// TTL of the created and updated records. Short TTLs let clients pick up a new address quickly, so it defaults to
+	// hosttech.MinimalTTL.
+	TTL time.Duration
+
+	// Logger receives the hostnames whose records could not be updated, e.g. because the API failed. The answer to
+	// the client is just dnserr. Defaults to log.Default().
+	Logger *log.Logger
+}
+
+// Handler returns the HTTP handler serving GET /nic/update.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /nic/update", s.update)
+	return mux
+}
+
+// update handles an update request, e.g. /nic/update?hostname=home.example.com&myip=1.2.3.4. Multiple hostnames
+// and an IPv4 and IPv6 address may be given comma separated. Without myip, the address of the client is used. There
+// is one line in the response for every hostname.
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	username, password, ok := r.BasicAuth()
+	if !ok || !s.knownCredentials(username, password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="dyndns"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, "badauth")
+		return
+	}
+
+	hostnames := splitList(r.URL.Query().Get("hostname"))
+	if len(hostnames) == 0 {
+		fmt.Fprintln(w, "notfqdn")
+		return
+	}
+
+	//The protocol has no code for an invalid address, badagent tells the client that its request is malformed and
+	//must not be repeated
+	addresses, err := s.addresses(r)
+	if err != nil {
+		fmt.Fprintln(w, "badagent")
+		return
+	}
+
+	for _, hostname := range hostnames {
+		hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+
+		host, ok := s.Hosts[hostname]
+		if !ok || !equalCredentials(host, username, password) {
+			fmt.Fprintln(w, "nohost")
+			continue
+		}
+
+		changed, err := s.updateHost(r.Context(), hostname, host, addresses)
+		if err != nil {
+			s.logger().Printf("could not update '%s': %s", hostname, err)
+			fmt.Fprintln(w, "dnserr")
+			continue
+		}
+
+		status := "nochg"
+		if changed {
+			status = "good"
+		}
+		fmt.Fprintf(w, "%s %s\n", status, joinAddresses(addresses))
+	}
+}
+
+// updateHost sets the A and AAAA records of the hostname to the addresses, creating the records that don't exist yet.
+// It returns whether any record changed.
+func (s *Server) updateHost(ctx context.Context, hostname string, host Host, addresses []netip.Addr) (bool, error) {
+	zone := strings.TrimSuffix(host.Zone, ".")
+	name := libdns.RelativeName(hostname, zone)
+
+	records, err := s.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return false, err
+	}
+
+	var newRecords, changedRecords []libdns.Record
+	for _, address := range addresses {
+		recordType := "A"
+		if address.Is6() {
+			recordType = "AAAA"
+		}
+
+		record, unchanged := currentRecord(records, name, recordType, address.String())
+		if unchanged {
+			continue
+		}
+
+		record.Type = recordType
+		record.Name = name
+		record.Value = address.String()
+		record.TTL = s.ttl()
+		if record.ID == "" {
+			newRecords = append(newRecords, record)
+		} else {
+			changedRecords = append(changedRecords, record)
+		}
+	}
+
+	if len(newRecords) == 0 && len(changedRecords) == 0 {
+		return false, nil
+	}
+
+	if len(newRecords) > 0 {
+		_, err = s.Provider.AppendRecords(ctx, zone, newRecords)
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(changedRecords) > 0 {
+		_, err = s.Provider.SetRecords(ctx, zone, changedRecords)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// currentRecord returns the record of the name and type to update and whether one of them already has the value.
+func currentRecord(records []libdns.Record, name string, recordType string, value string) (libdns.Record, bool) {
+	var current libdns.Record
+	for _, record := range records {
+		if record.Type != recordType || !strings.EqualFold(record.Name, name) {
+			continue
+		}
+
+		if record.Value == value {
+			return record, true
+		}
+		if current.ID == "" {
+			current = record
+		}
+	}
+
+	return current, false
+}
+
+// addresses returns the addresses of the myip parameter, or the address of the client if there is none.
+func (s *Server) addresses(r *http.Request) ([]netip.Addr, error) {
+	values := splitList(r.URL.Query().Get("myip"))
+	if len(values) == 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return nil, err
+		}
+		values = []string{host}
+	}
+
+	var addresses []netip.Addr
+	seenFamilies := map[bool]bool{}
+	for _, value := range values {
+		address, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, err
+		}
+		address = address.Unmap()
+
+		//Only one address per family, as a hostname has a single A and AAAA record
+		if seenFamilies[address.Is6()] {
+			return nil, fmt.Errorf("got more than one %s address", familyName(address))
+		}
+		seenFamilies[address.Is6()] = true
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// knownCredentials reports whether any host has the credentials.
+func (s *Server) knownCredentials(username string, password string) bool {
+	known := false
+	for _, host := range s.Hosts {
+		if equalCredentials(host, username, password) {
+			known = true
+		}
+	}
+	return known
+}
+
+// equalCredentials compares the credentials in constant time. Hosts without a password can't be updated.
+func equalCredentials(host Host, username string, password string) bool {
+	if host.Password == "" {
+		return false
+	}
+
+	usernameMatches := subtle.ConstantTimeCompare([]byte(host.Username), []byte(username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare([]byte(host.Password), []byte(password)) == 1
+	return usernameMatches && passwordMatches
+}
+
+func (s *Server) ttl() time.Duration {
+	if s.TTL == 0 {
+		return hosttech.MinimalTTL
+	}
+	return s.TTL
+}
+
+func (s *Server) logger() *log.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return log.Default()
+}
+
+func familyName(address netip.Addr) string {
+	if address.Is6() {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+func joinAddresses(addresses []netip.Addr) string {
+	var values []string
+	for _, address := range addresses {
+		values = append(values, address.String())
+	}
+	return strings.Join(values, ",")
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Interface guard
+var _ Provider = (*hosttech.Provider)(nil)
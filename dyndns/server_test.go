@@ -0,0 +1,126 @@
+package dyndns
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Update(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "office", "ipv4": "1.2.3.4", "ttl": 600})
+
+	//New records are created, existing ones are updated
+	methods := map[string]int{}
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods[r.Method]++
+		next.ServeHTTP(w, r)
+	})
+
+	server := &Server{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Hosts: map[string]Host{
+			"home.example.com":   {Zone: "example.com", Username: "home", Password: "secret"},
+			"office.example.com": {Zone: "example.com", Username: "office", Password: "secret"},
+		},
+		Logger: log.New(io.Discard, "", 0),
+	}
+	handler := server.Handler()
+
+	update := func(query string, username string, password string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/nic/update?"+query, nil)
+		if username != "" {
+			request.SetBasicAuth(username, password)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	//Rejected requests do not change the zone
+	input := map[string]struct {
+		query            string
+		username         string
+		password         string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		"Without Credentials Test": {
+			query:            "hostname=home.example.com&myip=1.2.3.5",
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: "badauth\n",
+		},
+		"Wrong Password Test": {
+			query:            "hostname=home.example.com&myip=1.2.3.5",
+			username:         "home",
+			password:         "wrong",
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: "badauth\n",
+		},
+		"Hostname Of Other Credentials Test": {
+			query:            "hostname=office.example.com&myip=1.2.3.5",
+			username:         "home",
+			password:         "secret",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "nohost\n",
+		},
+		"Missing Hostname Test": {
+			query:            "myip=1.2.3.5",
+			username:         "home",
+			password:         "secret",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "notfqdn\n",
+		},
+		"Invalid Address Test": {
+			query:            "hostname=home.example.com&myip=1.2.3",
+			username:         "home",
+			password:         "secret",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "badagent\n",
+		},
+		"Two Addresses Of A Family Test": {
+			query:            "hostname=home.example.com&myip=1.2.3.5,1.2.3.6",
+			username:         "home",
+			password:         "secret",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "badagent\n",
+		},
+	}
+
+	for desc, tc := range input {
+		t.Run(desc, func(t *testing.T) {
+			recorder := update(tc.query, tc.username, tc.password)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			assert.Equal(t, tc.expectedResponse, recorder.Body.String())
+		})
+	}
+	assert.Len(t, api.Records("example.com"), 1)
+
+	recorder := update("hostname=home.example.com&myip=1.2.3.5,2001:db8::1", "home", "secret")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "good 1.2.3.5,2001:db8::1\n", recorder.Body.String())
+
+	recorder = update("hostname=home.example.com&myip=1.2.3.5,2001:db8::1", "home", "secret")
+	assert.Equal(t, "nochg 1.2.3.5,2001:db8::1\n", recorder.Body.String())
+
+	//Without myip, the address of the client is used
+	recorder = update("hostname=office.example.com", "office", "secret")
+	assert.Equal(t, "good 192.0.2.1\n", recorder.Body.String())
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 3)
+	assert.Equal(t, "192.0.2.1", records[0]["ipv4"])
+	assert.Equal(t, "1.2.3.5", records[1]["ipv4"])
+	assert.Equal(t, "2001:db8::1", records[2]["ipv6"])
+	assert.Equal(t, 2, methods[http.MethodPost])
+	assert.Equal(t, 1, methods[http.MethodPut])
+}
@@ -0,0 +1,304 @@
+// Package exporter exposes the record inventory of Hosttech.ch zones and the health of the Hosttech API as
+// Prometheus metrics.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+)
+
+// The upper bounds of the TTL histogram in seconds. The first is hosttech.MinimalTTL, no record can have less.
+var ttlBuckets = []int{600, 1800, 3600, 7200, 14400, 43200, 86400}
+
+// Provider lists the zones of the account and reads their records on every refresh, it never changes anything.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.ZoneLister
+}
+
+// Exporter periodically reads the records of the zones and serves metrics about them.
+type Exporter struct {
+	Provider Provider
+
+	// Zones are the zones to export. Without zones, all zones of the account are listed on every refresh.
+	Zones []string
+
+	// Interval between two refreshes. Defaults to 5 minutes.
+	Interval time.Duration
+
+	// Logger receives a line for every failed refresh. The zone is reported as down but keeps the record counts of
+	// its last successful refresh. If it is nil, log.Default() is used.
+	Logger *log.Logger
+
+	mu        sync.Mutex
+	zones     map[string]*zoneState
+	apiErrors map[string]int
+}
+
+// zoneState holds the metrics of a zone as of the last refresh.
+type zoneState struct {
+	up            bool
+	recordsByType map[string]int
+	ttlCounts     []int
+	ttlSum        float64
+	ttlCount      int
+	lastSuccess   time.Time
+	duration      time.Duration
+	errors        int
+}
+
+// Run refreshes the metrics right away and then after every interval, until the context is canceled.
+func (e *Exporter) Run(ctx context.Context) error {
+	for {
+		err := e.Refresh(ctx)
+		if err != nil {
+			e.logger().Printf("refresh failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.interval()):
+		}
+	}
+}
+
+// Refresh reads the records of all zones once and updates the metrics. A zone that can not be read keeps the
+// inventory of its last successful refresh.
+func (e *Exporter) Refresh(ctx context.Context) error {
+	zones := e.Zones
+	if len(zones) == 0 {
+		listedZones, err := e.Provider.ListZones(ctx)
+		if err != nil {
+			e.countAPIError(err)
+			return fmt.Errorf("could not list zones: %w", err)
+		}
+
+		zones = []string{}
+		for _, zone := range listedZones {
+			zones = append(zones, strings.TrimSuffix(zone.Name, "."))
+		}
+		e.forgetZonesExcept(zones)
+	}
+
+	var errs []error
+	for _, zone := range zones {
+		start := time.Now()
+		records, err := e.Provider.GetRecords(ctx, zone)
+		duration := time.Since(start)
+		if err != nil {
+			e.countAPIError(err)
+			errs = append(errs, fmt.Errorf("could not get records of zone '%s': %w", zone, err))
+		}
+
+		e.updateZone(zone, records, duration, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (e *Exporter) updateZone(zone string, records []libdns.Record, duration time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.zones == nil {
+		e.zones = map[string]*zoneState{}
+	}
+	state, ok := e.zones[zone]
+	if !ok {
+		state = &zoneState{}
+		e.zones[zone] = state
+	}
+
+	state.duration = duration
+	if err != nil {
+		state.up = false
+		state.errors++
+		return
+	}
+
+	state.up = true
+	state.lastSuccess = time.Now()
+	state.recordsByType = map[string]int{}
+	state.ttlCounts = make([]int, len(ttlBuckets))
+	state.ttlSum = 0
+	state.ttlCount = len(records)
+	for _, record := range records {
+		state.recordsByType[record.Type]++
+
+		ttl := record.TTL.Seconds()
+		state.ttlSum += ttl
+		for i, bound := range ttlBuckets {
+			if ttl <= float64(bound) {
+				state.ttlCounts[i]++
+			}
+		}
+	}
+}
+
+// forgetZonesExcept drops the metrics of zones that no longer exist in the account.
+func (e *Exporter) forgetZonesExcept(zones []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keep := map[string]bool{}
+	for _, zone := range zones {
+		keep[zone] = true
+	}
+	for zone := range e.zones {
+		if !keep[zone] {
+			delete(e.zones, zone)
+		}
+	}
+}
+
+// countAPIError counts the error by the status code of the API. Errors without a response are counted as "none".
+func (e *Exporter) countAPIError(err error) {
+	status := "none"
+	var apiError hosttech.ApiError
+	if errors.As(err, &apiError) {
+		status = strconv.Itoa(apiError.ErrorCode)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.apiErrors == nil {
+		e.apiErrors = map[string]int{}
+	}
+	e.apiErrors[status]++
+}
+
+// Handler returns the HTTP handler serving GET /metrics in the Prometheus text format.
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		e.WriteMetrics(w)
+	})
+	return mux
+}
+
+// WriteMetrics writes all metrics in the Prometheus text format, sorted by zone.
+func (e *Exporter) WriteMetrics(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var zones []string
+	for zone := range e.zones {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	writeHeader(w, "hosttech_zone_up", "gauge", "Whether the last refresh of the zone succeeded.")
+	for _, zone := range zones {
+		fmt.Fprintf(w, "hosttech_zone_up{zone=%s} %d\n", label(zone), boolValue(e.zones[zone].up))
+	}
+
+	writeHeader(w, "hosttech_zone_records", "gauge", "Number of records in the zone by type.")
+	for _, zone := range zones {
+		state := e.zones[zone]
+		var types []string
+		for recordType := range state.recordsByType {
+			types = append(types, recordType)
+		}
+		sort.Strings(types)
+		for _, recordType := range types {
+			fmt.Fprintf(w, "hosttech_zone_records{zone=%s,type=%s} %d\n", label(zone), label(recordType), state.recordsByType[recordType])
+		}
+	}
+
+	writeHeader(w, "hosttech_zone_record_ttl_seconds", "histogram", "Distribution of the TTLs of the records in the zone.")
+	for _, zone := range zones {
+		state := e.zones[zone]
+		if state.lastSuccess.IsZero() {
+			continue
+		}
+		for i, bound := range ttlBuckets {
+			fmt.Fprintf(w, "hosttech_zone_record_ttl_seconds_bucket{zone=%s,le=\"%d\"} %d\n", label(zone), bound, state.ttlCounts[i])
+		}
+		fmt.Fprintf(w, "hosttech_zone_record_ttl_seconds_bucket{zone=%s,le=\"+Inf\"} %d\n", label(zone), state.ttlCount)
+		fmt.Fprintf(w, "hosttech_zone_record_ttl_seconds_sum{zone=%s} %s\n", label(zone), formatFloat(state.ttlSum))
+		fmt.Fprintf(w, "hosttech_zone_record_ttl_seconds_count{zone=%s} %d\n", label(zone), state.ttlCount)
+	}
+
+	writeHeader(w, "hosttech_zone_last_success_timestamp_seconds", "gauge", "Unix time of the last successful refresh of the zone.")
+	for _, zone := range zones {
+		state := e.zones[zone]
+		if !state.lastSuccess.IsZero() {
+			fmt.Fprintf(w, "hosttech_zone_last_success_timestamp_seconds{zone=%s} %d\n", label(zone), state.lastSuccess.Unix())
+		}
+	}
+
+	writeHeader(w, "hosttech_zone_refresh_duration_seconds", "gauge", "Duration of the last refresh of the zone.")
+	for _, zone := range zones {
+		fmt.Fprintf(w, "hosttech_zone_refresh_duration_seconds{zone=%s} %s\n", label(zone), formatFloat(e.zones[zone].duration.Seconds()))
+	}
+
+	writeHeader(w, "hosttech_zone_refresh_errors_total", "counter", "Number of failed refreshes of the zone.")
+	for _, zone := range zones {
+		fmt.Fprintf(w, "hosttech_zone_refresh_errors_total{zone=%s} %d\n", label(zone), e.zones[zone].errors)
+	}
+
+	var statuses []string
+	for status := range e.apiErrors {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	writeHeader(w, "hosttech_api_errors_total", "counter", "Number of failed requests to the Hosttech API by status code.")
+	for _, status := range statuses {
+		fmt.Fprintf(w, "hosttech_api_errors_total{status=%s} %d\n", label(status), e.apiErrors[status])
+	}
+}
+
+func writeHeader(w io.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label quotes a label value as required by the Prometheus text format.
+func label(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func boolValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (e *Exporter) interval() time.Duration {
+	if e.Interval == 0 {
+		return 5 * time.Minute
+	}
+	return e.Interval
+}
+
+func (e *Exporter) logger() *log.Logger {
+	if e.Logger != nil {
+		return e.Logger
+	}
+	return log.Default()
+}
+
+// Interface guard
+var _ Provider = (*hosttech.Provider)(nil)
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporter(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com", "example.org")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "", "text": "v=spf1 -all", "ttl": 86400})
+
+	provider := &hosttech.Provider{APIToken: "token", APIURL: api.URL}
+	exporter := &Exporter{Provider: provider}
+	assert.NoError(t, exporter.Refresh(context.Background()))
+
+	unknown := &Exporter{Provider: provider, Zones: []string{"example.com", "unknown.com"}}
+	assert.Error(t, unknown.Refresh(context.Background()))
+
+	metrics := scrape(t, exporter)
+	assert.Contains(t, metrics, `hosttech_zone_up{zone="example.com"} 1`)
+	assert.Contains(t, metrics, `hosttech_zone_up{zone="example.org"} 1`)
+	assert.Contains(t, metrics, `hosttech_zone_records{zone="example.com",type="A"} 2`)
+	assert.Contains(t, metrics, `hosttech_zone_records{zone="example.com",type="TXT"} 1`)
+	assert.Contains(t, metrics, `hosttech_zone_record_ttl_seconds_bucket{zone="example.com",le="600"} 1`)
+	assert.Contains(t, metrics, `hosttech_zone_record_ttl_seconds_bucket{zone="example.com",le="3600"} 2`)
+	assert.Contains(t, metrics, `hosttech_zone_record_ttl_seconds_bucket{zone="example.com",le="+Inf"} 3`)
+	assert.Contains(t, metrics, `hosttech_zone_record_ttl_seconds_sum{zone="example.com"} 90600`)
+	assert.Contains(t, metrics, `hosttech_zone_last_success_timestamp_seconds{zone="example.com"}`)
+
+	metrics = scrape(t, unknown)
+	assert.Contains(t, metrics, `hosttech_zone_up{zone="unknown.com"} 0`)
+	assert.Contains(t, metrics, `hosttech_zone_refresh_errors_total{zone="unknown.com"} 1`)
+	assert.Contains(t, metrics, `hosttech_api_errors_total{status="404"} 1`)
+	assert.NotContains(t, metrics, `hosttech_zone_last_success_timestamp_seconds{zone="unknown.com"}`)
+}
+
+func scrape(t *testing.T, exporter *Exporter) string {
+	response := httptest.NewRecorder()
+	exporter.Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
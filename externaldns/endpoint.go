@@ -0,0 +1,115 @@
+package externaldns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Endpoint is the representation of a DNS name and its targets in the external-dns webhook protocol.
+type Endpoint struct {
+	DNSName          string                     `json:"dnsName,omitempty"`
+	Targets          []string                   `json:"targets,omitempty"`
+	RecordType       string                     `json:"recordType,omitempty"`
+	SetIdentifier    string                     `json:"setIdentifier,omitempty"`
+	RecordTTL        int64                      `json:"recordTTL,omitempty"`
+	Labels           map[string]string          `json:"labels,omitempty"`
+	ProviderSpecific []ProviderSpecificProperty `json:"providerSpecific,omitempty"`
+}
+
+// ProviderSpecificProperty holds a provider specific name/value pair of an endpoint.
+type ProviderSpecificProperty struct {
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// Changes holds the endpoints that external-dns wants to create, update and delete.
+// UpdateOld and UpdateNew contain the same endpoints before and after the update, at the same positions.
+type Changes struct {
+	Create    []*Endpoint `json:"Create,omitempty"`
+	UpdateOld []*Endpoint `json:"UpdateOld,omitempty"`
+	UpdateNew []*Endpoint `json:"UpdateNew,omitempty"`
+	Delete    []*Endpoint `json:"Delete,omitempty"`
+}
+
+// DomainFilter is returned on negotiation to tell external-dns which domains the webhook manages.
+type DomainFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// toEndpoints groups the records of a zone by name and type into endpoints.
+func toEndpoints(zone string, records []libdns.Record) []*Endpoint {
+	var endpoints []*Endpoint
+	byKey := map[string]*Endpoint{}
+	for _, record := range records {
+		dnsName := libdns.AbsoluteName(record.Name, zone)
+		key := dnsName + "\x00" + record.Type
+
+		endpoint, ok := byKey[key]
+		if !ok {
+			endpoint = &Endpoint{
+				DNSName:    dnsName,
+				RecordType: record.Type,
+				RecordTTL:  int64(record.TTL.Seconds()),
+				Labels:     map[string]string{},
+			}
+			byKey[key] = endpoint
+			endpoints = append(endpoints, endpoint)
+		}
+
+		endpoint.Targets = append(endpoint.Targets, toTarget(record))
+	}
+
+	return endpoints
+}
+
+// toRecords converts an endpoint into one record per target, with names relative to the zone.
+func toRecords(zone string, endpoint *Endpoint) ([]libdns.Record, error) {
+	var records []libdns.Record
+	for _, target := range endpoint.Targets {
+		record := libdns.Record{
+			Type:  endpoint.RecordType,
+			Name:  libdns.RelativeName(strings.TrimSuffix(endpoint.DNSName, "."), zone),
+			Value: target,
+			TTL:   time.Duration(endpoint.RecordTTL) * time.Second,
+		}
+
+		//external-dns writes the preference of MX records in front of the mail server
+		if endpoint.RecordType == "MX" {
+			preference, host, found := strings.Cut(target, " ")
+			if !found {
+				return nil, fmt.Errorf(`MX target "%s" has no preference`, target)
+			}
+			priority, err := strconv.ParseUint(preference, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf(`MX target "%s" has an invalid preference: %w`, target, err)
+			}
+			record.Priority = uint(priority)
+			record.Value = host
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func toTarget(record libdns.Record) string {
+	if record.Type == "MX" {
+		return fmt.Sprintf("%d %s", record.Priority, record.Value)
+	}
+	return record.Value
+}
+
+// sameValue compares record values. TXT values are compared without surrounding quotes, because the TXT registry of
+// external-dns quotes its ownership records.
+func sameValue(recordType string, a string, b string) bool {
+	if recordType == "TXT" {
+		return strings.Trim(a, `"`) == strings.Trim(b, `"`)
+	}
+	return a == b
+}
@@ -0,0 +1,328 @@
+// Package externaldns implements the webhook provider protocol of external-dns on top of the Hosttech.ch
+// libdns provider, so Hosttech zones can be managed by external-dns from Kubernetes.
+package externaldns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+)
+
+// MediaType is the content type of all requests and responses of version 1 of the webhook protocol
+const MediaType = "application/external.dns.webhook+json;version=1"
+
+// hosttech.MinimalTTL in seconds, the unit of the TTLs of endpoints
+const minimalTTL = int64(hosttech.MinimalTTL / time.Second)
+
+// Provider lists the records external-dns sees as endpoints and applies the creations, updates and deletions it
+// requests.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+	libdns.RecordDeleter
+}
+
+// Webhook serves the external-dns webhook API for a set of zones.
+type Webhook struct {
+	Provider Provider
+
+	// Zones are the zones managed by the webhook. They are announced to external-dns as the domain filter.
+	Zones []string
+
+	// Logger receives the requests of external-dns that failed and the responses that could not be written. If it is
+	// nil, log.Default() is used.
+	Logger *log.Logger
+}
+
+// Handler returns the HTTP handler serving the webhook API:
+//
+//	GET  /                 negotiation, returns the domain filter
+//	GET  /records          returns all records of the zones as endpoints
+//	POST /records          applies the changes
+//	POST /adjustendpoints  adjusts endpoints to what the provider supports
+func (w *Webhook) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", w.negotiate)
+	mux.HandleFunc("GET /records", w.records)
+	mux.HandleFunc("POST /records", w.applyChanges)
+	mux.HandleFunc("POST /adjustendpoints", w.adjustEndpoints)
+	return mux
+}
+
+func (w *Webhook) negotiate(rw http.ResponseWriter, r *http.Request) {
+	w.writeJSON(rw, http.StatusOK, DomainFilter{Include: w.Zones})
+}
+
+func (w *Webhook) records(rw http.ResponseWriter, r *http.Request) {
+	endpoints := []*Endpoint{}
+	for _, zone := range w.Zones {
+		records, err := w.Provider.GetRecords(r.Context(), zone)
+		if err != nil {
+			w.writeError(rw, http.StatusInternalServerError, fmt.Errorf("could not read records of zone '%s': %w", zone, err))
+			return
+		}
+
+		endpoints = append(endpoints, toEndpoints(zone, records)...)
+	}
+
+	w.writeJSON(rw, http.StatusOK, endpoints)
+}
+
+func (w *Webhook) applyChanges(rw http.ResponseWriter, r *http.Request) {
+	if !w.acceptsContentType(rw, r) {
+		return
+	}
+
+	var changes Changes
+	err := json.NewDecoder(r.Body).Decode(&changes)
+	if err != nil {
+		w.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	err = w.ApplyChanges(r.Context(), changes)
+	if err != nil {
+		w.writeError(rw, http.StatusInternalServerError, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (w *Webhook) adjustEndpoints(rw http.ResponseWriter, r *http.Request) {
+	if !w.acceptsContentType(rw, r) {
+		return
+	}
+
+	var endpoints []*Endpoint
+	err := json.NewDecoder(r.Body).Decode(&endpoints)
+	if err != nil {
+		w.writeError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	w.writeJSON(rw, http.StatusOK, AdjustEndpoints(endpoints))
+}
+
+// AdjustEndpoints drops endpoints with record types Hosttech does not support and raises TTLs below the minimum
+// of the API, so external-dns does not try to apply changes that would be rejected or never converge.
+func AdjustEndpoints(endpoints []*Endpoint) []*Endpoint {
+	adjusted := []*Endpoint{}
+	for _, endpoint := range endpoints {
+		_, err := hosttech.LibdnsRecordToHosttechRecordWrapper(libdns.Record{Type: endpoint.RecordType})
+		if err != nil {
+			continue
+		}
+
+		if endpoint.RecordTTL > 0 && endpoint.RecordTTL < minimalTTL {
+			endpoint.RecordTTL = minimalTTL
+		}
+
+		adjusted = append(adjusted, endpoint)
+	}
+
+	return adjusted
+}
+
+// ApplyChanges applies the changes requested by external-dns. Deletions are applied first, then updates and finally
+// creations, so a CNAME never coexists with another record of the same name.
+func (w *Webhook) ApplyChanges(ctx context.Context, changes Changes) error {
+	if len(changes.UpdateOld) != len(changes.UpdateNew) {
+		return fmt.Errorf("got %d old and %d new endpoints to update", len(changes.UpdateOld), len(changes.UpdateNew))
+	}
+
+	for _, endpoint := range changes.Delete {
+		err := w.deleteEndpoint(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range changes.UpdateNew {
+		err := w.updateEndpoint(ctx, changes.UpdateOld[i], changes.UpdateNew[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, endpoint := range changes.Create {
+		zone, err := w.zoneFor(endpoint.DNSName)
+		if err != nil {
+			return err
+		}
+
+		records, err := toRecords(zone, endpoint)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Provider.AppendRecords(ctx, zone, records)
+		if err != nil {
+			return fmt.Errorf("could not create %s record '%s': %w", endpoint.RecordType, endpoint.DNSName, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *Webhook) deleteEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	zone, err := w.zoneFor(endpoint.DNSName)
+	if err != nil {
+		return err
+	}
+
+	existing, err := w.existingRecords(ctx, zone, endpoint)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := toRecords(zone, endpoint)
+	if err != nil {
+		return err
+	}
+
+	var toDelete []libdns.Record
+	for _, record := range existing {
+		for _, target := range deleted {
+			if sameValue(record.Type, record.Value, target.Value) && record.Priority == target.Priority {
+				toDelete = append(toDelete, record)
+				break
+			}
+		}
+	}
+
+	_, err = w.Provider.DeleteRecords(ctx, zone, toDelete)
+	if err != nil {
+		return fmt.Errorf("could not delete %s record '%s': %w", endpoint.RecordType, endpoint.DNSName, err)
+	}
+
+	return nil
+}
+
+func (w *Webhook) updateEndpoint(ctx context.Context, old *Endpoint, new *Endpoint) error {
+	zone, err := w.zoneFor(new.DNSName)
+	if err != nil {
+		return err
+	}
+
+	existing, err := w.existingRecords(ctx, zone, old)
+	if err != nil {
+		return err
+	}
+
+	desired, err := toRecords(zone, new)
+	if err != nil {
+		return err
+	}
+
+	//Keep TXT values that only differ in their quotes as they are stored, so the records of the TXT registry of
+	//external-dns are not rewritten on every update
+	for i := range desired {
+		for _, record := range existing {
+			if sameValue(record.Type, record.Value, desired[i].Value) {
+				desired[i].Value = record.Value
+				break
+			}
+		}
+	}
+
+	for _, change := range hosttech.DiffRecords(zone, existing, desired) {
+		switch change.Action {
+		case hosttech.ChangeDelete:
+			_, err = w.Provider.DeleteRecords(ctx, zone, []libdns.Record{change.Current})
+		case hosttech.ChangeUpdate:
+			record := change.Desired
+			record.ID = change.Current.ID
+			_, err = w.Provider.SetRecords(ctx, zone, []libdns.Record{record})
+		case hosttech.ChangeCreate:
+			_, err = w.Provider.AppendRecords(ctx, zone, []libdns.Record{change.Desired})
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not update %s record '%s': %w", new.RecordType, new.DNSName, err)
+		}
+	}
+
+	return nil
+}
+
+// existingRecords returns the records in the zone with the name and type of the endpoint.
+func (w *Webhook) existingRecords(ctx context.Context, zone string, endpoint *Endpoint) ([]libdns.Record, error) {
+	records, err := w.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(endpoint.DNSName, ".")
+	var existing []libdns.Record
+	for _, record := range records {
+		if record.Type == endpoint.RecordType && strings.EqualFold(libdns.AbsoluteName(record.Name, zone), name) {
+			existing = append(existing, record)
+		}
+	}
+
+	return existing, nil
+}
+
+// zoneFor returns the most specific zone the DNS name belongs to.
+func (w *Webhook) zoneFor(dnsName string) (string, error) {
+	dnsName = strings.ToLower(strings.TrimSuffix(dnsName, "."))
+
+	zones := append([]string{}, w.Zones...)
+	sort.Slice(zones, func(i, j int) bool { return len(zones[i]) > len(zones[j]) })
+	for _, zone := range zones {
+		zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+		if dnsName == zone || strings.HasSuffix(dnsName, "."+zone) {
+			return zone, nil
+		}
+	}
+
+	return "", fmt.Errorf("'%s' does not belong to any of the zones %s", dnsName, strings.Join(w.Zones, ", "))
+}
+
+// acceptsContentType makes sure the request body is in the format of the webhook protocol.
+func (w *Webhook) acceptsContentType(rw http.ResponseWriter, r *http.Request) bool {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	expectedType, expectedParams, _ := mime.ParseMediaType(MediaType)
+	if err != nil || mediaType != expectedType || params["version"] != expectedParams["version"] {
+		w.writeError(rw, http.StatusUnsupportedMediaType, fmt.Errorf("expected content type '%s'", MediaType))
+		return false
+	}
+	return true
+}
+
+func (w *Webhook) writeJSON(rw http.ResponseWriter, status int, body any) {
+	rw.Header().Set("Content-Type", MediaType)
+	rw.Header().Set("Vary", "Content-Type")
+	rw.WriteHeader(status)
+
+	err := json.NewEncoder(rw).Encode(body)
+	if err != nil {
+		w.logger().Printf("could not write response: %s", err)
+	}
+}
+
+func (w *Webhook) writeError(rw http.ResponseWriter, status int, err error) {
+	w.logger().Printf("request failed: %s", err)
+	http.Error(rw, err.Error(), status)
+}
+
+func (w *Webhook) logger() *log.Logger {
+	if w.Logger != nil {
+		return w.Logger
+	}
+	return log.Default()
+}
+
+// Interface guard
+var _ Provider = (*hosttech.Provider)(nil)
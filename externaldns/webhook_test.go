@@ -0,0 +1,182 @@
+package externaldns
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryProvider keeps the records of a single zone in memory
+type memoryProvider struct {
+	records []libdns.Record
+	nextId  int
+}
+
+func (m *memoryProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return append([]libdns.Record{}, m.records...), nil
+}
+
+func (m *memoryProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	for i := range records {
+		m.nextId++
+		records[i].ID = strconv.Itoa(m.nextId)
+		m.records = append(m.records, records[i])
+	}
+	return records, nil
+}
+
+func (m *memoryProvider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	for _, record := range records {
+		for i := range m.records {
+			if m.records[i].ID == record.ID {
+				m.records[i] = record
+			}
+		}
+	}
+	return records, nil
+}
+
+func (m *memoryProvider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	for _, record := range records {
+		for i := range m.records {
+			if m.records[i].ID == record.ID {
+				m.records = append(m.records[:i], m.records[i+1:]...)
+				break
+			}
+		}
+	}
+	return records, nil
+}
+
+func TestWebhook_ApplyChanges(t *testing.T) {
+	provider := &memoryProvider{nextId: 3, records: []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+		{ID: "2", Type: "TXT", Name: "a-www", Value: `heritage=external-dns,external-dns/owner=default`, TTL: 600 * time.Second},
+		{ID: "3", Type: "A", Name: "old", Value: "1.2.3.5", TTL: 600 * time.Second},
+	}}
+	webhook := Webhook{Provider: provider, Zones: []string{"example.com"}}
+
+	err := webhook.ApplyChanges(context.Background(), Changes{
+		Create: []*Endpoint{
+			{DNSName: "mail.example.com", RecordType: "MX", Targets: []string{"10 mx1.example.com", "20 mx2.example.com"}, RecordTTL: 3600},
+		},
+		UpdateOld: []*Endpoint{
+			{DNSName: "www.example.com", RecordType: "A", Targets: []string{"1.2.3.4"}, RecordTTL: 600},
+		},
+		UpdateNew: []*Endpoint{
+			{DNSName: "www.example.com", RecordType: "A", Targets: []string{"1.2.3.9"}, RecordTTL: 600},
+		},
+		Delete: []*Endpoint{
+			{DNSName: "old.example.com", RecordType: "A", Targets: []string{"1.2.3.5"}},
+			{DNSName: "a-www.example.com", RecordType: "TXT", Targets: []string{`"heritage=external-dns,external-dns/owner=default"`}},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.9", TTL: 600 * time.Second},
+		{ID: "4", Type: "MX", Name: "mail", Value: "mx1.example.com", TTL: 3600 * time.Second, Priority: 10},
+		{ID: "5", Type: "MX", Name: "mail", Value: "mx2.example.com", TTL: 3600 * time.Second, Priority: 20},
+	}, provider.records)
+}
+
+func TestWebhook_ApplyChangesQuotedTXT(t *testing.T) {
+	provider := &memoryProvider{nextId: 1, records: []libdns.Record{
+		{ID: "1", Type: "TXT", Name: "a-www", Value: `heritage=external-dns,external-dns/owner=default`, TTL: 600 * time.Second},
+	}}
+	webhook := Webhook{Provider: provider, Zones: []string{"example.com"}}
+
+	err := webhook.ApplyChanges(context.Background(), Changes{
+		UpdateOld: []*Endpoint{
+			{DNSName: "a-www.example.com", RecordType: "TXT", Targets: []string{`"heritage=external-dns,external-dns/owner=default"`}, RecordTTL: 600},
+		},
+		UpdateNew: []*Endpoint{
+			{DNSName: "a-www.example.com", RecordType: "TXT", Targets: []string{`"heritage=external-dns,external-dns/owner=default"`, `"heritage=external-dns,external-dns/owner=other"`}, RecordTTL: 600},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []libdns.Record{
+		{ID: "1", Type: "TXT", Name: "a-www", Value: `heritage=external-dns,external-dns/owner=default`, TTL: 600 * time.Second},
+		{ID: "2", Type: "TXT", Name: "a-www", Value: `"heritage=external-dns,external-dns/owner=other"`, TTL: 600 * time.Second},
+	}, provider.records)
+}
+
+func TestWebhook_Handler(t *testing.T) {
+	provider := &memoryProvider{records: []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+		{ID: "2", Type: "A", Name: "www", Value: "1.2.3.5", TTL: 600 * time.Second},
+		{ID: "3", Type: "MX", Name: "", Value: "mail.example.com", TTL: 3600 * time.Second, Priority: 10},
+	}}
+	webhook := Webhook{Provider: provider, Zones: []string{"example.com"}}
+	server := httptest.NewServer(webhook.Handler())
+	defer server.Close()
+
+	input := map[string]struct {
+		expectedStatus int
+		expectedBody   string
+		method         string
+		path           string
+		contentType    string
+		body           string
+	}{
+		"Negotiate Test": {
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"include":["example.com"]}`,
+			method:         http.MethodGet,
+			path:           "/",
+		},
+		"Records Test": {
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"dnsName":"www.example.com","targets":["1.2.3.4","1.2.3.5"],"recordType":"A","recordTTL":600},{"dnsName":"example.com","targets":["10 mail.example.com"],"recordType":"MX","recordTTL":3600}]`,
+			method:         http.MethodGet,
+			path:           "/records",
+		},
+		"Adjust Endpoints Test": {
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"dnsName":"www.example.com","targets":["1.2.3.4"],"recordType":"A","recordTTL":600}]`,
+			method:         http.MethodPost,
+			path:           "/adjustendpoints",
+			contentType:    MediaType,
+			body:           `[{"dnsName":"www.example.com","targets":["1.2.3.4"],"recordType":"A","recordTTL":60},{"dnsName":"srv.example.com","targets":["0 5 5060 sip.example.com"],"recordType":"SRV"}]`,
+		},
+		"Wrong Content Type Test": {
+			expectedStatus: http.StatusUnsupportedMediaType,
+			method:         http.MethodPost,
+			path:           "/records",
+			contentType:    "application/json",
+			body:           `{}`,
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			request, err := http.NewRequest(testStruct.method, server.URL+testStruct.path, strings.NewReader(testStruct.body))
+			assert.NoError(t, err)
+			request.Header.Set("Accept", MediaType)
+			if testStruct.contentType != "" {
+				request.Header.Set("Content-Type", testStruct.contentType)
+			}
+
+			response, err := http.DefaultClient.Do(request)
+			assert.NoError(t, err)
+			defer response.Body.Close()
+
+			assert.Equal(t, testStruct.expectedStatus, response.StatusCode)
+			if testStruct.expectedBody != "" {
+				assert.Equal(t, MediaType, response.Header.Get("Content-Type"))
+				body, err := io.ReadAll(response.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, testStruct.expectedBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package hosttech
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// RecordFilter selects records by type and name. Empty fields match every record.
+type RecordFilter struct {
+	Type string
+
+	// Name is relative to the zone, "@" selects the zone apex
+	Name string
+}
+
+// matches reports whether the record passes the filter.
+func (f RecordFilter) matches(zone string, record libdns.Record) bool {
+	if f.Type != "" && !strings.EqualFold(f.Type, record.Type) {
+		return false
+	}
+	if f.Name != "" && NormalizeName(f.Name, zone) != NormalizeName(record.Name, zone) {
+		return false
+	}
+	return true
+}
+
+// GetRecordsFiltered lists the records in the zone that pass the filter. The type is passed to the API, which only
+// returns records of that type. The name is filtered on the client, as well as the type, in case the API ignored it.
+// With caching enabled, the records are filtered from the cached zone.
+func (p *Provider) GetRecordsFiltered(ctx context.Context, zone string, filter RecordFilter) (_ []libdns.Record, err error) {
+	ctx, endSpan := p.startSpan(ctx, "GetRecordsFiltered", zone, nil)
+	defer endSpan(&err)
+
+	var hosttechRecords []HosttechRecordWrapper
+	if p.CacheTTL > 0 {
+		hosttechRecords, err = p.getCachedHosttechRecords(ctx, zone)
+	} else {
+		query := url.Values{}
+		if filter.Type != "" {
+			query.Set("type", strings.ToUpper(filter.Type))
+		}
+		hosttechRecords, err = p.listHosttechRecords(ctx, zone, query)
+	}
+	if err != nil {
+		return []libdns.Record{}, err
+	}
+
+	var libdnsRecords []libdns.Record
+	var matchingRecords []HosttechRecordWrapper
+	for _, hosttechRecord := range hosttechRecords {
+		record := hosttechRecord.toLibdnsRecord(zone)
+		if filter.matches(zone, record) {
+			libdnsRecords = append(libdnsRecords, record)
+			matchingRecords = append(matchingRecords, hosttechRecord)
+		}
+	}
+	p.rememberRecords(zone, matchingRecords, false)
+
+	return libdnsRecords, nil
+}
@@ -0,0 +1,58 @@
+package hosttech
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_GetRecordsFiltered(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "AAAA", "name": "www", "ipv6": "2001:db8::1", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "", "ipv4": "1.2.3.5", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "", "text": "v=spf1 -all", "ttl": 3600})
+
+	var queries []string
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		next.ServeHTTP(w, r)
+	})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	records, err := provider.GetRecordsFiltered(ctx, "example.com", RecordFilter{Type: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.2.3.4", "1.2.3.5"}, []string{records[0].Value, records[1].Value})
+	assert.Equal(t, []string{"type=A"}, queries)
+
+	records, err = provider.GetRecordsFiltered(ctx, "example.com", RecordFilter{Name: "www.example.com."})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A", "AAAA"}, []string{records[0].Type, records[1].Type})
+
+	records, err = provider.GetRecordsFiltered(ctx, "example.com", RecordFilter{Type: "TXT", Name: "@"})
+	assert.NoError(t, err)
+	assert.Equal(t, []libdns.Record{{ID: "4", Type: "TXT", Name: "", Value: "v=spf1 -all", TTL: 3600 * time.Second}}, records)
+
+	records, err = provider.GetRecordsFiltered(ctx, "example.com", RecordFilter{Type: "MX"})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	//With caching, the cached zone is filtered
+	cached := Provider{APIToken: "token", APIURL: api.URL, CacheTTL: time.Minute}
+	queries = nil
+	for _, filter := range []RecordFilter{{Type: "A"}, {Name: "www"}} {
+		records, err = cached.GetRecordsFiltered(ctx, "example.com", filter)
+		assert.NoError(t, err)
+		assert.Len(t, records, 2)
+	}
+	assert.Equal(t, []string{""}, queries)
+}
@@ -0,0 +1,378 @@
+// Package hosttechtest provides an in-memory fake of the Hosttech DNS API for tests. It can additionally serve the
+// records it holds over DNS, to test code that waits for records to become visible.
+package hosttechtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Server is a fake of the Hosttech DNS API. Records are kept as the JSON objects sent by the client, so every
+// record type of the API is supported.
+type Server struct {
+	*httptest.Server
+
+	// Token is the bearer token the server expects
+	Token string
+
+	// PageSize splits the lists of zones and records into pages of this size, linked with "links" and "meta" like
+	// the paginated responses of the Hosttech API. Zero returns every list on a single page.
+	PageSize int
+
+	mu         sync.Mutex
+	zones      map[string][]map[string]any
+	nextId     int
+	dnsServers []*dns.Server
+}
+
+// NewServer starts a fake API that accepts the token and manages the zones. Requests for other zones fail with 404.
+func NewServer(token string, zones ...string) *Server {
+	s := &Server{
+		Token: token,
+		zones: map[string][]map[string]any{},
+	}
+	for _, zone := range zones {
+		s.zones[zone] = []map[string]any{}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /zones", s.listZones)
+	mux.HandleFunc("GET /zones/{zone}/records", s.listRecords)
+	mux.HandleFunc("POST /zones/{zone}/records", s.createRecord)
+	mux.HandleFunc("PUT /zones/{zone}/records/{id}", s.updateRecord)
+	mux.HandleFunc("DELETE /zones/{zone}/records/{id}", s.deleteRecord)
+	s.Server = httptest.NewServer(s.authenticated(mux))
+
+	return s
+}
+
+// Close shuts down the API and all DNS servers.
+func (s *Server) Close() {
+	s.Server.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, server := range s.dnsServers {
+		server.Shutdown()
+	}
+}
+
+// Records returns a copy of the records of the zone as JSON objects.
+func (s *Server) Records(zone string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []map[string]any
+	for _, record := range s.zones[zone] {
+		records = append(records, copyRecord(record))
+	}
+	return records
+}
+
+// AddRecord stores a record in the zone as if it was created through the API and returns its id.
+func (s *Server) AddRecord(zone string, record map[string]any) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addRecord(zone, record)
+}
+
+// StartDNS serves the records of all zones over UDP on the address, e.g. "127.0.0.1:0". It returns the address the
+// DNS server listens on. The DNS server is shut down together with the API.
+func (s *Server) StartDNS(address string) (string, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return "", err
+	}
+
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(s.serveDNS)}
+	go server.ActivateAndServe()
+
+	s.mu.Lock()
+	s.dnsServers = append(s.dnsServers, server)
+	s.mu.Unlock()
+
+	return conn.LocalAddr().String(), nil
+}
+
+func (s *Server) authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"message": "Unauthenticated."})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listZones(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for name := range s.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	zones := []map[string]any{}
+	for i, name := range names {
+		zones = append(zones, map[string]any{"id": i + 1, "name": name})
+	}
+
+	s.writeList(w, r, zones)
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, ok := s.zones[r.PathValue("zone")]
+	if !ok {
+		writeNotFound(w)
+		return
+	}
+
+	if recordType := r.URL.Query().Get("type"); recordType != "" {
+		var filtered []map[string]any
+		for _, record := range records {
+			if stringField(record, "type") == recordType {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	s.writeList(w, r, records)
+}
+
+// writeList writes the page of the items requested with the "page" query parameter, starting at 1.
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, items []map[string]any) {
+	if items == nil {
+		items = []map[string]any{}
+	}
+	if s.PageSize <= 0 {
+		writeJSON(w, http.StatusOK, map[string]any{"data": items})
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	lastPage := max(1, (len(items)+s.PageSize-1)/s.PageSize)
+
+	start := min(len(items), (page-1)*s.PageSize)
+	end := min(len(items), start+s.PageSize)
+
+	var next any
+	if page < lastPage {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page+1))
+		next = s.URL + r.URL.Path + "?" + query.Encode()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data":  items[start:end],
+		"links": map[string]any{"next": next},
+		"meta": map[string]any{
+			"current_page": page,
+			"last_page":    lastPage,
+			"per_page":     s.PageSize,
+			"total":        len(items),
+		},
+	})
+}
+
+func (s *Server) createRecord(w http.ResponseWriter, r *http.Request) {
+	var record map[string]any
+	err := json.NewDecoder(r.Body).Decode(&record)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone := r.PathValue("zone")
+	if _, ok := s.zones[zone]; !ok {
+		writeNotFound(w)
+		return
+	}
+
+	s.addRecord(zone, record)
+	writeJSON(w, http.StatusCreated, map[string]any{"data": record})
+}
+
+func (s *Server) updateRecord(w http.ResponseWriter, r *http.Request) {
+	var update map[string]any
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, _ := s.findRecord(r.PathValue("zone"), r.PathValue("id"))
+	if record == nil {
+		writeNotFound(w)
+		return
+	}
+
+	for key, value := range update {
+		if key != "id" && key != "type" {
+			record[key] = value
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": record})
+}
+
+func (s *Server) deleteRecord(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone := r.PathValue("zone")
+	_, index := s.findRecord(zone, r.PathValue("id"))
+	if index < 0 {
+		writeNotFound(w)
+		return
+	}
+
+	s.zones[zone] = append(s.zones[zone][:index], s.zones[zone][index+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addRecord(zone string, record map[string]any) int {
+	s.nextId++
+	record["id"] = s.nextId
+	s.zones[zone] = append(s.zones[zone], record)
+	return s.nextId
+}
+
+func (s *Server) findRecord(zone string, id string) (map[string]any, int) {
+	for i, record := range s.zones[zone] {
+		if fmt.Sprint(record["id"]) == id {
+			return record, i
+		}
+	}
+	return nil, -1
+}
+
+// serveDNS answers queries with the records of the zones that match the name and type of the question.
+func (s *Server) serveDNS(w dns.ResponseWriter, r *dns.Msg) {
+	response := new(dns.Msg)
+	response.SetReply(r)
+	response.Authoritative = true
+
+	s.mu.Lock()
+	for _, question := range r.Question {
+		response.Answer = append(response.Answer, s.answers(question)...)
+	}
+	s.mu.Unlock()
+
+	w.WriteMsg(response)
+}
+
+func (s *Server) answers(question dns.Question) []dns.RR {
+	var zones []string
+	for zone := range s.zones {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	var answers []dns.RR
+	for _, zone := range zones {
+		for _, record := range s.zones[zone] {
+			rr, err := toRR(zone, record)
+			if err != nil {
+				continue
+			}
+
+			header := rr.Header()
+			if strings.EqualFold(header.Name, question.Name) && (question.Qtype == dns.TypeANY || header.Rrtype == question.Qtype) {
+				answers = append(answers, rr)
+			}
+		}
+	}
+
+	return answers
+}
+
+// toRR converts a record of the API into a resource record.
+func toRR(zone string, record map[string]any) (dns.RR, error) {
+	recordType := fmt.Sprint(record["type"])
+	name := stringField(record, "name")
+
+	var value string
+	switch recordType {
+	case "A":
+		value = stringField(record, "ipv4")
+	case "AAAA":
+		value = stringField(record, "ipv6")
+	case "CNAME":
+		value = dns.Fqdn(stringField(record, "cname"))
+	case "MX":
+		name = stringField(record, "ownername")
+		value = fmt.Sprintf("%v %s", record["pref"], dns.Fqdn(stringField(record, "name")))
+	case "NS":
+		name = stringField(record, "ownername")
+		value = dns.Fqdn(stringField(record, "targetname"))
+	case "TXT":
+		value = strconv.Quote(stringField(record, "text"))
+	case "TLSA":
+		value = stringField(record, "text")
+	default:
+		return nil, fmt.Errorf("record type %s is not supported", recordType)
+	}
+
+	fqdn := dns.Fqdn(zone)
+	name = strings.TrimSuffix(name, ".")
+	if name != "" && name != "@" && !strings.HasSuffix(name, zone) {
+		fqdn = dns.Fqdn(name + "." + zone)
+	} else if strings.HasSuffix(name, zone) {
+		fqdn = dns.Fqdn(name)
+	}
+
+	ttl, err := strconv.Atoi(fmt.Sprint(record["ttl"]))
+	if err != nil {
+		ttl = 3600
+	}
+
+	return dns.NewRR(fmt.Sprintf("%s %d IN %s %s", fqdn, ttl, recordType, value))
+}
+
+func stringField(record map[string]any, key string) string {
+	value, _ := record[key].(string)
+	return value
+}
+
+func copyRecord(record map[string]any) map[string]any {
+	copied := map[string]any{}
+	for key, value := range record {
+		copied[key] = value
+	}
+	return copied
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]any{"message": "Not found."})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
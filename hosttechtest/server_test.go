@@ -0,0 +1,102 @@
+package hosttechtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Provider(t *testing.T) {
+	server := NewServer("token", "example.com")
+	defer server.Close()
+
+	provider := hosttech.Provider{APIToken: "token", APIURL: server.URL}
+	ctx := context.Background()
+	zone := "example.com"
+
+	appended, err := provider.AppendRecords(ctx, zone, []libdns.Record{
+		{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+		{Type: "MX", Name: "", Value: "mail.example.com", TTL: 3600 * time.Second, Priority: 10},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, []string{appended[0].ID, appended[1].ID})
+
+	updated, err := provider.SetRecords(ctx, zone, []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.5", TTL: 600 * time.Second},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3.5", updated[0].Value)
+
+	_, err = provider.DeleteRecords(ctx, zone, []libdns.Record{appended[1]})
+	assert.NoError(t, err)
+
+	records, err := provider.GetRecords(ctx, zone)
+	assert.NoError(t, err)
+	assert.Equal(t, []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.5", TTL: 600 * time.Second},
+	}, records)
+
+	_, err = (&hosttech.Provider{APIToken: "wrong", APIURL: server.URL}).GetRecords(ctx, zone)
+	assert.ErrorAs(t, err, &hosttech.ApiError{})
+
+	_, err = provider.GetRecords(ctx, "unknown.com")
+	assert.ErrorAs(t, err, &hosttech.ApiError{})
+
+	zones, err := provider.ListZones(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []libdns.Zone{{Name: "example.com"}}, zones)
+}
+
+func TestServer_StartDNS(t *testing.T) {
+	server := NewServer("token", "example.com")
+	defer server.Close()
+
+	server.AddRecord("example.com", map[string]any{"type": "TXT", "name": "_acme-challenge", "text": "challenge", "ttl": 600})
+	server.AddRecord("example.com", map[string]any{"type": "MX", "ownername": "", "name": "mail.example.com", "pref": 10, "ttl": 600})
+
+	address, err := server.StartDNS("127.0.0.1:0")
+	assert.NoError(t, err)
+
+	input := map[string]struct {
+		expectedResult []string
+		name           string
+		queryType      uint16
+	}{
+		"TXT Test": {
+			expectedResult: []string{"_acme-challenge.example.com.\t600\tIN\tTXT\t\"challenge\""},
+			name:           "_acme-challenge.example.com.",
+			queryType:      dns.TypeTXT,
+		},
+		"MX Test": {
+			expectedResult: []string{"example.com.\t600\tIN\tMX\t10 mail.example.com."},
+			name:           "example.com.",
+			queryType:      dns.TypeMX,
+		},
+		"Missing Record Test": {
+			expectedResult: nil,
+			name:           "www.example.com.",
+			queryType:      dns.TypeA,
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			message := new(dns.Msg)
+			message.SetQuestion(testStruct.name, testStruct.queryType)
+
+			response, err := dns.Exchange(message, address)
+			assert.NoError(t, err)
+
+			var answers []string
+			for _, answer := range response.Answer {
+				answers = append(answers, answer.String())
+			}
+			assert.Equal(t, testStruct.expectedResult, answers)
+		})
+	}
+}
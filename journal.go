@@ -0,0 +1,341 @@
+package hosttech
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// JournalOperation is the kind of change recorded in a journal entry.
+type JournalOperation string
+
+const (
+	JournalAppend JournalOperation = "append"
+	JournalSet    JournalOperation = "set"
+	JournalDelete JournalOperation = "delete"
+	// JournalUndo reverts the entry referenced by Undoes.
+	JournalUndo JournalOperation = "undo"
+)
+
+// ErrNothingToUndo is returned by Undo if every operation in the journal has been undone already.
+var ErrNothingToUndo = errors.New("journal has no operation left to undo")
+
+// Journal appends an entry for every change made by the provider to a local file, one JSON object per line.
+// Changes in dry-run mode are not recorded.
+type Journal struct {
+	// Path of the journal file. It is created if it does not exist.
+	Path string `json:"path,omitempty"`
+
+	// Label identifies the caller in the entries, e.g. the name of a tool or a user
+	Label string `json:"label,omitempty"`
+
+	mu sync.Mutex
+}
+
+// JournalEntry is a single change in the journal. Before holds the records as they were before the change, including
+// their comments, After the records as they were written.
+type JournalEntry struct {
+	ID        string           `json:"id"`
+	Time      time.Time        `json:"time"`
+	Label     string           `json:"label,omitempty"`
+	Zone      string           `json:"zone"`
+	Operation JournalOperation `json:"operation"`
+	Before    []SnapshotRecord `json:"before,omitempty"`
+	After     []SnapshotRecord `json:"after,omitempty"`
+
+	// Undoes is the ID of the entry reverted by an undo. It is empty if the undo failed part way.
+	Undoes string `json:"undoes,omitempty"`
+
+	// Error describes why an undo failed part way
+	Error string `json:"error,omitempty"`
+}
+
+// Entries reads all entries of the journal, oldest first.
+func (j *Journal) Entries() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.Open(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []JournalEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []JournalEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		entry := JournalEntry{}
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse journal '%s': %w", j.Path, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// append adds the entry to the end of the journal, setting its ID, time and label.
+func (j *Journal) append(entry JournalEntry) (JournalEntry, error) {
+	id := make([]byte, 8)
+	rand.Read(id)
+	entry.ID = hex.EncodeToString(id)
+	entry.Time = time.Now().UTC()
+	entry.Label = j.Label
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.OpenFile(j.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return entry, err
+	}
+
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return entry, err
+}
+
+// Undo reverts the most recent operation in the journal that has not been undone yet, regardless of its label.
+// Appended records are deleted, updated records are set back to their previous state, including their comment, and
+// deleted records are recreated with new IDs. The undo is recorded in the journal and returned.
+// An undo that fails part way can be repeated, records that were already reverted are skipped.
+func (p *Provider) Undo(ctx context.Context) (_ JournalEntry, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Undo", "", nil)
+	defer endSpan(&err)
+
+	if p.Journal == nil {
+		return JournalEntry{}, fmt.Errorf("undo needs a journal")
+	}
+
+	ctx, entry, unlock, err := p.lockUndoableEntry(ctx)
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	defer unlock()
+
+	undo := JournalEntry{Zone: entry.Zone, Operation: JournalUndo}
+	err = p.revert(ctx, entry, &undo)
+	if err != nil {
+		undo.Error = err.Error()
+	} else {
+		undo.Undoes = entry.ID
+	}
+
+	if !p.journalEnabled() {
+		return undo, err
+	}
+
+	undo, journalErr := p.Journal.append(undo)
+	if journalErr != nil {
+		return undo, errors.Join(err, fmt.Errorf("could not write journal: %w", journalErr))
+	}
+
+	return undo, err
+}
+
+// revert applies the inverse of the entry and records the reverted records in the undo entry.
+func (p *Provider) revert(ctx context.Context, entry JournalEntry, undo *JournalEntry) error {
+	previous := map[string]SnapshotRecord{}
+	for _, record := range entry.Before {
+		previous[record.ID] = record
+	}
+
+	switch entry.Operation {
+	case JournalAppend, JournalSet:
+		for _, record := range entry.After {
+			before, existed := previous[record.ID]
+			if !existed {
+				err := p.deleteIfExists(ctx, entry.Zone, record.Record())
+				if err != nil {
+					return err
+				}
+				undo.Before = append(undo.Before, record)
+				continue
+			}
+
+			writtenRecords, err := p.writeRecord(ctx, entry.Zone, before.Record(), before.Comment)
+			if err != nil {
+				return err
+			}
+			undo.Before = append(undo.Before, record)
+			undo.After = append(undo.After, newSnapshotRecord(writtenRecords[0], before.Comment))
+		}
+
+	case JournalDelete:
+		current, err := p.GetRecords(ctx, entry.Zone)
+		if err != nil {
+			return err
+		}
+
+		for _, before := range entry.Before {
+			record := before.Record()
+			record.ID = ""
+
+			//Recreated by an earlier, failed undo
+			if indexOfRecord(entry.Zone, current, record, true) >= 0 {
+				continue
+			}
+
+			writtenRecords, err := p.writeRecord(ctx, entry.Zone, record, before.Comment)
+			if err != nil {
+				return err
+			}
+			undo.After = append(undo.After, newSnapshotRecord(writtenRecords[0], before.Comment))
+		}
+
+	default:
+		return fmt.Errorf(`journal operation "%s" can not be undone`, entry.Operation)
+	}
+
+	return nil
+}
+
+// deleteIfExists deletes the record, ignoring that it was deleted already.
+func (p *Provider) deleteIfExists(ctx context.Context, zone string, record libdns.Record) error {
+	_, err := p.deleteRecords(ctx, zone, []libdns.Record{record})
+
+	var apiError ApiError
+	if errors.As(err, &apiError) && apiError.ErrorCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// lockUndoableEntry returns the entry to undo with its zone locked. The journal is read again once the zone is
+// locked, because a concurrent undo may have taken the entry while waiting for the lock.
+func (p *Provider) lockUndoableEntry(ctx context.Context) (context.Context, JournalEntry, func(), error) {
+	for {
+		entries, err := p.Journal.Entries()
+		if err != nil {
+			return ctx, JournalEntry{}, nil, err
+		}
+
+		entry, ok := lastUndoableEntry(entries)
+		if !ok {
+			return ctx, JournalEntry{}, nil, ErrNothingToUndo
+		}
+
+		lockedCtx, unlock, err := p.lockZone(ctx, entry.Zone)
+		if err != nil {
+			return ctx, JournalEntry{}, nil, err
+		}
+
+		entries, err = p.Journal.Entries()
+		if err != nil {
+			unlock()
+			return ctx, JournalEntry{}, nil, err
+		}
+
+		if lockedEntry, ok := lastUndoableEntry(entries); ok && lockedEntry.ID == entry.ID {
+			return lockedCtx, entry, unlock, nil
+		}
+		unlock()
+	}
+}
+
+// lastUndoableEntry returns the most recent entry that is neither an undo nor undone.
+func lastUndoableEntry(entries []JournalEntry) (JournalEntry, bool) {
+	undone := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Operation == JournalUndo && entry.Undoes != "" {
+			undone[entry.Undoes] = true
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Operation != JournalUndo && !undone[entry.ID] {
+			return entry, true
+		}
+	}
+
+	return JournalEntry{}, false
+}
+
+func (p *Provider) journalEnabled() bool {
+	return p.Journal != nil && !p.DryRun
+}
+
+// journalState returns the records with the IDs as they are before a change, if the journal is enabled. The records
+// are read from the API, bypassing the cache and the records remembered for conflict checks.
+func (p *Provider) journalState(ctx context.Context, zone string, ids []string) ([]SnapshotRecord, error) {
+	if !p.journalEnabled() || len(ids) == 0 {
+		return nil, nil
+	}
+
+	hosttechRecords, err := p.getHosttechRecords(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var state []SnapshotRecord
+	for _, hosttechRecord := range hosttechRecords {
+		record := newRecordWithComment(zone, hosttechRecord)
+		if wanted[record.ID] {
+			state = append(state, newSnapshotRecord(record.Record, record.Comment))
+		}
+	}
+
+	return state, nil
+}
+
+// journalChange records a change in the journal, if it is enabled. The changed records are the ones returned by the
+// mutating method, which may be fewer than requested if an error occurred. The error of the method is returned,
+// joined with the error of writing the journal.
+func (p *Provider) journalChange(zone string, operation JournalOperation, before []SnapshotRecord, changed []libdns.Record, err error) error {
+	if !p.journalEnabled() || len(changed) == 0 {
+		return err
+	}
+
+	changedIds := map[string]bool{}
+	for _, record := range changed {
+		changedIds[record.ID] = true
+	}
+
+	entry := JournalEntry{Zone: zone, Operation: operation}
+	for _, record := range before {
+		if changedIds[record.ID] {
+			entry.Before = append(entry.Before, record)
+		}
+	}
+	if operation != JournalDelete {
+		for _, record := range changed {
+			entry.After = append(entry.After, newSnapshotRecord(record, ""))
+		}
+	}
+
+	_, journalErr := p.Journal.append(entry)
+	if journalErr != nil {
+		return errors.Join(err, fmt.Errorf("could not write journal: %w", journalErr))
+	}
+
+	return err
+}
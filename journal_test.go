@@ -0,0 +1,65 @@
+package hosttech
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Undo(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600, "comment": "www server"})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600, "comment": "api server"})
+
+	journal := &Journal{Path: filepath.Join(t.TempDir(), "journal.jsonl"), Label: "test"}
+	provider := Provider{APIToken: "token", APIURL: api.URL, Journal: journal}
+	ctx := context.Background()
+	initial, err := provider.Snapshot(ctx, "example.com")
+	assert.NoError(t, err)
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	_, err = provider.DeleteRecords(ctx, "example.com", records[1:])
+	assert.NoError(t, err)
+	records[0].Value = "9.9.9.9"
+	_, err = provider.SetRecords(ctx, "example.com", records[:1])
+	assert.NoError(t, err)
+	_, err = provider.AppendRecords(ctx, "example.com", []libdns.Record{{Type: "TXT", Name: "new", Value: "hello", TTL: 3600 * time.Second}})
+	assert.NoError(t, err)
+
+	entries, err := journal.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, JournalDelete, entries[0].Operation)
+	assert.Equal(t, "api server", entries[0].Before[0].Comment)
+	assert.Equal(t, "1.2.3.4", entries[1].Before[0].Value)
+	assert.Equal(t, "9.9.9.9", entries[1].After[0].Value)
+	assert.Equal(t, "test", entries[2].Label)
+
+	for i := 0; i < 3; i++ {
+		undo, err := provider.Undo(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, entries[2-i].ID, undo.Undoes)
+	}
+
+	_, err = provider.Undo(ctx)
+	assert.ErrorIs(t, err, ErrNothingToUndo)
+
+	//The zone matches its initial state again, including the comments
+	plan, err := provider.PlanRestore(ctx, initial)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty())
+	for _, record := range api.Records("example.com") {
+		assert.Equal(t, record["name"].(string)+" server", record["comment"])
+	}
+
+	entries, err = journal.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6)
+}
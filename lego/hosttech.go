@@ -0,0 +1,166 @@
+// Package lego implements a DNS provider for solving the DNS-01 challenge of go-acme/lego using Hosttech.ch,
+// on top of the libdns provider of github.com/libdns/hosttech.
+package lego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/config/env"
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+)
+
+// Environment variables names.
+const (
+	envNamespace = "HOSTTECH_"
+
+	EnvAPIToken = envNamespace + "API_TOKEN"
+
+	EnvTTL                = envNamespace + "TTL"
+	EnvPropagationTimeout = envNamespace + "PROPAGATION_TIMEOUT"
+	EnvPollingInterval    = envNamespace + "POLLING_INTERVAL"
+)
+
+// The minimal TTL in seconds accepted by the Hosttech API
+const minTTL = int(hosttech.MinimalTTL / time.Second)
+
+var _ challenge.ProviderTimeout = (*DNSProvider)(nil)
+
+// Config is used to configure the creation of the DNSProvider.
+type Config struct {
+	APIToken           string
+	PropagationTimeout time.Duration
+	PollingInterval    time.Duration
+	TTL                int
+}
+
+// NewDefaultConfig returns a default configuration for the DNSProvider.
+func NewDefaultConfig() *Config {
+	return &Config{
+		TTL:                env.GetOrDefaultInt(EnvTTL, minTTL),
+		PropagationTimeout: env.GetOrDefaultSecond(EnvPropagationTimeout, 5*time.Minute),
+		PollingInterval:    env.GetOrDefaultSecond(EnvPollingInterval, dns01.DefaultPollingInterval),
+	}
+}
+
+// DNSProvider implements the challenge.Provider interface.
+type DNSProvider struct {
+	config   *Config
+	provider *hosttech.Provider
+}
+
+// NewDNSProvider returns a DNSProvider instance configured for Hosttech.
+// Credentials must be passed in the environment variable: HOSTTECH_API_TOKEN.
+func NewDNSProvider() (*DNSProvider, error) {
+	values, err := env.Get(EnvAPIToken)
+	if err != nil {
+		return nil, fmt.Errorf("hosttech: %w", err)
+	}
+
+	config := NewDefaultConfig()
+	config.APIToken = values[EnvAPIToken]
+
+	return NewDNSProviderConfig(config)
+}
+
+// NewDNSProviderConfig returns a DNSProvider instance configured for Hosttech.
+func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
+	if config == nil {
+		return nil, errors.New("hosttech: the configuration of the DNS provider is nil")
+	}
+
+	if config.APIToken == "" {
+		return nil, errors.New("hosttech: credentials missing")
+	}
+
+	if config.TTL < minTTL {
+		return nil, fmt.Errorf("hosttech: invalid TTL, TTL (%d) must be greater than %d", config.TTL, minTTL)
+	}
+
+	return &DNSProvider{
+		config:   config,
+		provider: &hosttech.Provider{APIToken: config.APIToken},
+	}, nil
+}
+
+// Timeout returns the timeout and interval to use when checking for DNS propagation.
+func (d *DNSProvider) Timeout() (timeout, interval time.Duration) {
+	return d.config.PropagationTimeout, d.config.PollingInterval
+}
+
+// Present creates a TXT record to fulfill the dns-01 challenge.
+func (d *DNSProvider) Present(domain, token, keyAuth string) error {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	zone, subDomain, err := findZone(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("hosttech: %w", err)
+	}
+
+	_, err = d.provider.AppendRecords(context.Background(), zone, []libdns.Record{
+		{
+			Type:  "TXT",
+			Name:  subDomain,
+			Value: info.Value,
+			TTL:   time.Duration(d.config.TTL) * time.Second,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("hosttech: failed to add TXT record: fqdn=%s: %w", info.EffectiveFQDN, err)
+	}
+
+	return nil
+}
+
+// CleanUp removes the TXT records matching the specified parameters.
+func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	info := dns01.GetChallengeInfo(domain, keyAuth)
+
+	zone, subDomain, err := findZone(info.EffectiveFQDN)
+	if err != nil {
+		return fmt.Errorf("hosttech: %w", err)
+	}
+
+	ctx := context.Background()
+
+	records, err := d.provider.GetRecords(ctx, zone)
+	if err != nil {
+		return fmt.Errorf("hosttech: %w", err)
+	}
+
+	var challengeRecords []libdns.Record
+	for _, record := range records {
+		if record.Type == "TXT" && hosttech.NormalizeName(record.Name, zone) == hosttech.NormalizeName(subDomain, zone) && record.Value == info.Value {
+			challengeRecords = append(challengeRecords, record)
+		}
+	}
+
+	_, err = d.provider.DeleteRecords(ctx, zone, challengeRecords)
+	if err != nil {
+		return fmt.Errorf("hosttech: failed to delete TXT record: fqdn=%s: %w", info.EffectiveFQDN, err)
+	}
+
+	return nil
+}
+
+// findZone returns the zone of the FQDN and the name of the FQDN relative to it.
+func findZone(fqdn string) (zone string, subDomain string, err error) {
+	authZone, err := dns01.FindZoneByFqdn(fqdn)
+	if err != nil {
+		return "", "", fmt.Errorf("could not find zone for %q: %w", fqdn, err)
+	}
+
+	zone = dns01.UnFqdn(authZone)
+
+	subDomain, err = dns01.ExtractSubDomain(fqdn, zone)
+	if err != nil {
+		return "", "", err
+	}
+
+	return zone, subDomain, nil
+}
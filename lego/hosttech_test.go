@@ -0,0 +1,66 @@
+package lego
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDNSProvider(t *testing.T) {
+	input := map[string]struct {
+		expectedError  string
+		expectedConfig Config
+		envVars        map[string]string
+	}{
+		"Defaults Test": {
+			expectedConfig: Config{
+				APIToken:           "123",
+				PropagationTimeout: 5 * time.Minute,
+				PollingInterval:    2 * time.Second,
+				TTL:                600,
+			},
+			envVars: map[string]string{EnvAPIToken: "123"},
+		},
+		"Custom Values Test": {
+			expectedConfig: Config{
+				APIToken:           "123",
+				PropagationTimeout: 10 * time.Minute,
+				PollingInterval:    10 * time.Second,
+				TTL:                3600,
+			},
+			envVars: map[string]string{
+				EnvAPIToken:           "123",
+				EnvTTL:                "3600",
+				EnvPropagationTimeout: "600",
+				EnvPollingInterval:    "10",
+			},
+		},
+		"Missing Credentials Test": {
+			expectedError: "hosttech: some credentials information are missing: HOSTTECH_API_TOKEN",
+			envVars:       map[string]string{EnvAPIToken: ""},
+		},
+		"TTL Too Low Test": {
+			expectedError: "hosttech: invalid TTL, TTL (60) must be greater than 600",
+			envVars:       map[string]string{EnvAPIToken: "123", EnvTTL: "60"},
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{EnvAPIToken, EnvTTL, EnvPropagationTimeout, EnvPollingInterval} {
+				t.Setenv(key, testStruct.envVars[key])
+			}
+
+			provider, err := NewDNSProvider()
+
+			if testStruct.expectedError != "" {
+				assert.EqualError(t, err, testStruct.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testStruct.expectedConfig, *provider.config)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package hosttech
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// How often a lock file held by another process is retried
+const lockFileRetryInterval = 50 * time.Millisecond
+
+// zoneLockKey marks a context whose caller holds the lock of the zone in the provider, so nested calls of the same
+// provider, e.g. Sync calling SetRecords, do not wait for themselves. Other providers still take their own lock.
+type zoneLockKey struct {
+	provider *Provider
+	zone     string
+}
+
+// lockZone serializes the changes to the zone. It waits until no other call of the provider, and with LockDir no
+// other process, changes the zone, or until the context is canceled. The returned context must be passed to nested
+// calls, the returned function releases the lock.
+func (p *Provider) lockZone(ctx context.Context, zone string) (context.Context, func(), error) {
+	zone = zoneKey(zone)
+	if ctx.Value(zoneLockKey{p, zone}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	//The zone names the lock file, it must not point outside of the lock directory
+	if p.LockDir != "" && strings.ContainsAny(zone, `/\`) {
+		return ctx, nil, fmt.Errorf("could not lock zone '%s': the name contains a path separator", zone)
+	}
+
+	lock := p.zoneLock(zone)
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx, nil, ctx.Err()
+	}
+
+	unlock := func() { <-lock }
+	if p.LockDir != "" {
+		unlockFile, err := lockFile(ctx, filepath.Join(p.LockDir, zone+".lock"))
+		if err != nil {
+			unlock()
+			return ctx, nil, fmt.Errorf("could not lock zone '%s': %w", zone, err)
+		}
+		unlock = func() {
+			unlockFile()
+			<-lock
+		}
+	}
+
+	return context.WithValue(ctx, zoneLockKey{p, zone}, true), unlock, nil
+}
+
+// zoneLock returns the lock of the zone within this provider, a channel with room for a single holder.
+func (p *Provider) zoneLock(zone string) chan struct{} {
+	p.zoneLocksMu.Lock()
+	defer p.zoneLocksMu.Unlock()
+
+	if p.zoneLocks == nil {
+		p.zoneLocks = map[string]chan struct{}{}
+	}
+
+	lock, ok := p.zoneLocks[zone]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		p.zoneLocks[zone] = lock
+	}
+
+	return lock
+}
+
+// lockFile takes an exclusive lock on the file, creating it if needed, and retries until the lock is free or the
+// context is canceled.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				unlockFile(file)
+				file.Close()
+			}, nil
+		}
+
+		select {
+		case <-time.After(lockFileRetryInterval):
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package hosttech
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLockFile(file *os.File) (bool, error) {
+	return false, errors.New("lock files are not supported on this platform")
+}
+
+func unlockFile(file *os.File) {}
@@ -0,0 +1,101 @@
+package hosttech
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_ConcurrentSync(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	desired := []libdns.Record{{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second}}
+
+	//Without serialization, every call would plan the creation of the record
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := provider.Sync(context.Background(), "example.com", desired)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, api.Records("example.com"), 1)
+}
+
+func TestProvider_LockZone(t *testing.T) {
+	provider := Provider{}
+	lockedCtx, unlock, err := provider.lockZone(context.Background(), "example.com")
+	assert.NoError(t, err)
+
+	//Nested calls with the context of the holder do not wait
+	_, unlockNested, err := provider.lockZone(lockedCtx, "example.com.")
+	assert.NoError(t, err)
+	unlockNested()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, _, err = provider.lockZone(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, unlockOther, err := provider.lockZone(context.Background(), "example.org")
+	assert.NoError(t, err)
+	unlockOther()
+
+	//Another provider sharing the lock directory does not skip its lock with the context of the holder
+	dir := t.TempDir()
+	first, second := Provider{LockDir: dir}, Provider{LockDir: dir}
+	firstCtx, unlockFirst, err := first.lockZone(context.Background(), "example.com")
+	assert.NoError(t, err)
+	ctx, cancel = context.WithTimeout(firstCtx, 100*time.Millisecond)
+	defer cancel()
+	_, _, err = second.lockZone(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	unlockFirst()
+
+	unlock()
+	_, unlock, err = provider.lockZone(context.Background(), "example.com")
+	assert.NoError(t, err)
+	unlock()
+}
+
+func TestProvider_LockDir(t *testing.T) {
+	dir := t.TempDir()
+	first := Provider{LockDir: dir}
+	second := Provider{LockDir: dir}
+
+	_, unlock, err := first.lockZone(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.FileExists(t, dir+"/example.com.lock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err = second.lockZone(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	//Other spellings of the zone share the lock file
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, _, err = second.lockZone(ctx, "Example.COM.")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	unlock()
+	_, unlock, err = second.lockZone(context.Background(), "example.com")
+	assert.NoError(t, err)
+	unlock()
+
+	_, _, err = first.lockZone(context.Background(), "../example.com")
+	assert.ErrorContains(t, err, "path separator")
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "example.com.lock"))
+}
@@ -0,0 +1,127 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LogVerbosity controls which API calls the provider logs and in how much detail.
+type LogVerbosity string
+
+const (
+	// LogErrors logs failed API calls at error level. This is the default.
+	LogErrors LogVerbosity = ""
+	// LogCalls additionally logs every successful API call at info level.
+	LogCalls LogVerbosity = "calls"
+	// LogBodies additionally logs the request and response bodies of every successful API call in a second entry at
+	// debug level.
+	LogBodies LogVerbosity = "bodies"
+)
+
+// apiCall describes a finished API call for logging.
+type apiCall struct {
+	method       string
+	url          string
+	zone         string
+	attempt      int
+	status       int
+	latency      time.Duration
+	requestBody  []byte
+	responseBody []byte
+	err          error
+}
+
+// logApiCall writes an entry for the API call to the logger, depending on the verbosity. The API token is redacted
+// from everything that is logged.
+func (p *Provider) logApiCall(ctx context.Context, call apiCall) {
+	attrs := []slog.Attr{
+		slog.String("method", call.method),
+		slog.String("path", p.redact(apiPath(call.url))),
+		slog.String("zone", call.zone),
+		slog.Int("status", call.status),
+		slog.Duration("latency", call.latency),
+	}
+	if call.attempt > 1 {
+		attrs = append(attrs, slog.Int("attempt", call.attempt))
+	}
+	if p.DryRun && call.method != http.MethodGet {
+		attrs = append(attrs, slog.Bool("dry_run", true))
+	}
+	if count, ok := countRecords(call.responseBody); ok {
+		attrs = append(attrs, slog.Int("records", count))
+	}
+
+	if call.err != nil {
+		attrs = append(attrs, slog.String("error", p.redact(call.err.Error())))
+		p.Logger.LogAttrs(ctx, slog.LevelError, "hosttech API call failed", attrs...)
+		return
+	}
+
+	if p.LogVerbosity != LogCalls && p.LogVerbosity != LogBodies {
+		return
+	}
+	p.Logger.LogAttrs(ctx, slog.LevelInfo, "hosttech API call", attrs...)
+
+	//The bodies go into an entry of their own, so a logger at info level still sees the call
+	if p.LogVerbosity == LogBodies {
+		bodyAttrs := []slog.Attr{
+			slog.String("method", call.method),
+			slog.String("path", p.redact(apiPath(call.url))),
+		}
+		if call.requestBody != nil {
+			bodyAttrs = append(bodyAttrs, slog.String("request_body", p.redact(string(call.requestBody))))
+		}
+		if call.responseBody != nil {
+			bodyAttrs = append(bodyAttrs, slog.String("response_body", p.redact(string(call.responseBody))))
+		}
+		p.Logger.LogAttrs(ctx, slog.LevelDebug, "hosttech API call bodies", bodyAttrs...)
+	}
+}
+
+// redact replaces the API token, should it ever end up in a logged value.
+func (p *Provider) redact(value string) string {
+	if p.APIToken == "" {
+		return value
+	}
+	return strings.ReplaceAll(value, p.APIToken, "[REDACTED]")
+}
+
+// apiPath returns the path of the URL, so the host of the API is not repeated in every entry.
+func apiPath(reqUrl string) string {
+	parsed, err := url.Parse(reqUrl)
+	if err != nil {
+		return reqUrl
+	}
+	return parsed.Path
+}
+
+// countRecords returns the number of records in a response of the API. A single record counts as one.
+func countRecords(responseBody []byte) (int, bool) {
+	if len(responseBody) == 0 {
+		return 0, false
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	err := json.Unmarshal(responseBody, &response)
+	if err != nil || len(response.Data) == 0 {
+		return 0, false
+	}
+
+	if response.Data[0] != '[' {
+		return 1, true
+	}
+
+	var records []json.RawMessage
+	err = json.Unmarshal(response.Data, &records)
+	if err != nil {
+		return 0, false
+	}
+	return len(records), true
+}
@@ -0,0 +1,86 @@
+package hosttech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Logging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "missing.com") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data": [{"id": 10, "type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600}, {"id": 11, "type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600}]}`))
+	}))
+	defer server.Close()
+
+	input := map[string]struct {
+		verbosity       LogVerbosity
+		expectedEntries int
+	}{
+		"Errors Test": {
+			verbosity:       LogErrors,
+			expectedEntries: 1,
+		},
+		"Calls Test": {
+			verbosity:       LogCalls,
+			expectedEntries: 2,
+		},
+		"Bodies Test": {
+			verbosity:       LogBodies,
+			expectedEntries: 3,
+		},
+	}
+
+	for desc, tc := range input {
+		t.Run(desc, func(t *testing.T) {
+			var output bytes.Buffer
+			provider := Provider{
+				APIToken:     "secret-token",
+				APIURL:       server.URL + "/secret-token",
+				Logger:       slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})),
+				LogVerbosity: tc.verbosity,
+			}
+
+			_, err := provider.GetRecords(context.Background(), "example.com")
+			assert.NoError(t, err)
+			_, err = provider.GetRecords(context.Background(), "missing.com")
+			assert.Error(t, err)
+
+			assert.NotContains(t, output.String(), "secret-token")
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			assert.Len(t, lines, tc.expectedEntries)
+
+			var entry map[string]any
+			assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &entry))
+			assert.Equal(t, "ERROR", entry["level"])
+			assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+			assert.Equal(t, "/[REDACTED]/zones/missing.com/records", entry["path"])
+
+			if tc.verbosity != LogErrors {
+				assert.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+				assert.Equal(t, "INFO", entry["level"])
+				assert.Equal(t, "GET", entry["method"])
+				assert.Equal(t, float64(2), entry["records"])
+			}
+
+			if tc.verbosity == LogBodies {
+				entry = map[string]any{}
+				assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+				assert.Equal(t, "DEBUG", entry["level"])
+				assert.Equal(t, "hosttech API call bodies", entry["msg"])
+				assert.Contains(t, entry["response_body"], `"name": "api"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package hosttech
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// MigrationTarget is a provider records can be migrated to. Existing records are read first, so a migration can be
+// repeated without creating duplicates.
+type MigrationTarget interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+}
+
+// MigrationOptions controls which records are migrated and whether they are written.
+type MigrationOptions struct {
+	// DryRun only reports the changes, without writing any record
+	DryRun bool
+
+	// KeepApexNS migrates the NS records of the zone apex. They are skipped by default, as they belong to the
+	// previous host and are replaced by the nameservers of the new one.
+	KeepApexNS bool
+}
+
+// SkippedRecord is a record of the source zone that is not migrated.
+type SkippedRecord struct {
+	Record libdns.Record
+	Reason string
+}
+
+// TTLAdjustment is a record whose TTL was raised to the minimum accepted by the Hosttech API.
+type TTLAdjustment struct {
+	Record libdns.Record
+	From   time.Duration
+	To     time.Duration
+}
+
+// MigrationReport describes what a migration changed, or would change in dry-run mode.
+type MigrationReport struct {
+	SourceZone string
+	TargetZone string
+
+	// Changes are the creations and updates needed in the target zone
+	Changes []Change
+
+	// Applied are the changes that were written. It is empty in dry-run mode.
+	Applied []Change
+
+	Skipped        []SkippedRecord
+	TTLAdjustments []TTLAdjustment
+}
+
+// String renders the report in the same format as Plan, followed by the skipped records and the adjusted TTLs.
+func (r MigrationReport) String() string {
+	var sb strings.Builder
+	sb.WriteString(Plan{Zone: r.TargetZone, Changes: r.Changes}.String())
+	for _, skipped := range r.Skipped {
+		fmt.Fprintf(&sb, "! skipped %s: %s\n", formatRecord(skipped.Record), skipped.Reason)
+	}
+	for _, adjustment := range r.TTLAdjustments {
+		fmt.Fprintf(&sb, "! raised TTL of %s from %s\n", formatRecord(adjustment.Record), adjustment.From)
+	}
+
+	return sb.String()
+}
+
+// MigrateFrom copies all records of a zone at any libdns provider into the zone at Hosttech. Records of types the
+// API does not support are skipped, TTLs below the minimum are raised to it. Both are listed in the report.
+// Records that only exist at Hosttech are kept, records with the same name and type are overwritten.
+// If an error occurs while writing, the report lists the already applied changes.
+func (p *Provider) MigrateFrom(ctx context.Context, source libdns.RecordGetter, sourceZone string, zone string, options MigrationOptions) (_ MigrationReport, err error) {
+	ctx, endSpan := p.startSpan(ctx, "MigrateFrom", zone, nil)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return MigrationReport{SourceZone: sourceZone, TargetZone: zone}, err
+	}
+	defer unlock()
+
+	report := MigrationReport{SourceZone: sourceZone, TargetZone: zone}
+
+	records, err := source.GetRecords(ctx, sourceZone)
+	if err != nil {
+		return report, fmt.Errorf("could not get records of zone '%s': %w", sourceZone, err)
+	}
+
+	var desired []libdns.Record
+	for _, record := range records {
+		if skipApexNS(record, sourceZone, options) {
+			report.Skipped = append(report.Skipped, SkippedRecord{Record: record, Reason: "NS record of the zone apex"})
+			continue
+		}
+
+		_, err := LibdnsRecordToHosttechRecordWrapper(record)
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedRecord{Record: record, Reason: err.Error()})
+			continue
+		}
+
+		if record.TTL != 0 && record.TTL < MinimalTTL {
+			report.TTLAdjustments = append(report.TTLAdjustments, TTLAdjustment{Record: record, From: record.TTL, To: MinimalTTL})
+			record.TTL = MinimalTTL
+		}
+
+		_, err = p.validatedRecord(zone, record, "")
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedRecord{Record: record, Reason: err.Error()})
+			continue
+		}
+
+		desired = append(desired, record)
+	}
+
+	return migrate(ctx, p, zone, desired, report, options)
+}
+
+// MigrateTo copies all records of the zone at Hosttech into a zone at any libdns provider, e.g. to move away from
+// Hosttech. Records that only exist at the target are kept, records with the same name and type are overwritten.
+// Records the target does not accept fail the migration with the error of the target.
+func (p *Provider) MigrateTo(ctx context.Context, zone string, target MigrationTarget, targetZone string, options MigrationOptions) (_ MigrationReport, err error) {
+	ctx, endSpan := p.startSpan(ctx, "MigrateTo", zone, nil)
+	defer endSpan(&err)
+
+	report := MigrationReport{SourceZone: zone, TargetZone: targetZone}
+
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return report, fmt.Errorf("could not get records of zone '%s': %w", zone, err)
+	}
+
+	var desired []libdns.Record
+	for _, record := range records {
+		if skipApexNS(record, zone, options) {
+			report.Skipped = append(report.Skipped, SkippedRecord{Record: record, Reason: "NS record of the zone apex"})
+			continue
+		}
+
+		//IDs of Hosttech mean nothing to the target
+		record.ID = ""
+		desired = append(desired, record)
+	}
+
+	return migrate(ctx, target, targetZone, desired, report, options)
+}
+
+// migrate creates and updates the desired records in the target zone. Records that are not desired are kept.
+func migrate(ctx context.Context, target MigrationTarget, zone string, desired []libdns.Record, report MigrationReport, options MigrationOptions) (MigrationReport, error) {
+	current, err := target.GetRecords(ctx, zone)
+	if err != nil {
+		return report, fmt.Errorf("could not get records of zone '%s': %w", zone, err)
+	}
+
+	for _, change := range DiffRecords(zone, current, desired) {
+		if change.Action != ChangeDelete {
+			report.Changes = append(report.Changes, change)
+		}
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	for _, change := range report.Changes {
+		switch change.Action {
+		case ChangeUpdate:
+			record := change.Desired
+			record.ID = change.Current.ID
+			if record.TTL == 0 {
+				record.TTL = change.Current.TTL
+			}
+			_, err = target.SetRecords(ctx, zone, []libdns.Record{record})
+		case ChangeCreate:
+			_, err = target.AppendRecords(ctx, zone, []libdns.Record{change.Desired})
+		}
+
+		if err != nil {
+			return report, fmt.Errorf("could not migrate %s: %w", formatRecord(change.Desired), err)
+		}
+
+		report.Applied = append(report.Applied, change)
+	}
+
+	return report, nil
+}
+
+func skipApexNS(record libdns.Record, zone string, options MigrationOptions) bool {
+	return !options.KeepApexNS && strings.EqualFold(record.Type, "NS") && NormalizeName(record.Name, zone) == ""
+}
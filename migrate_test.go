@@ -0,0 +1,96 @@
+package hosttech
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryProvider is a libdns provider holding the records of a single zone in memory
+type memoryProvider struct {
+	records []libdns.Record
+}
+
+func (m *memoryProvider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return append([]libdns.Record{}, m.records...), nil
+}
+
+func (m *memoryProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	for _, record := range records {
+		record.ID = strconv.Itoa(len(m.records) + 1)
+		m.records = append(m.records, record)
+	}
+	return records, nil
+}
+
+func (m *memoryProvider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	for _, record := range records {
+		for i := range m.records {
+			if m.records[i].ID == record.ID {
+				m.records[i] = record
+			}
+		}
+	}
+	return records, nil
+}
+
+func TestProvider_MigrateFrom(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "", "ipv4": "9.9.9.9", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "legacy", "text": "keep me", "ttl": 3600})
+
+	source := &memoryProvider{records: []libdns.Record{
+		{ID: "1", Type: "A", Name: "@", Value: "1.2.3.4", TTL: 3600 * time.Second},
+		{ID: "2", Type: "CNAME", Name: "www", Value: "example.com.", TTL: 300 * time.Second},
+		{ID: "3", Type: "NS", Name: "@", Value: "ns1.other-host.net.", TTL: 3600 * time.Second},
+		{ID: "4", Type: "SRV", Name: "_sip._tcp", Value: "10 5060 sip.example.com.", TTL: 3600 * time.Second},
+		{ID: "5", Type: "A", Name: "broken", Value: "not an address", TTL: 3600 * time.Second},
+	}}
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+
+	report, err := provider.MigrateFrom(context.Background(), source, "example.com", "example.com", MigrationOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Len(t, report.Changes, 2)
+	assert.Empty(t, report.Applied)
+	assert.Len(t, report.Skipped, 3)
+	assert.Equal(t, []TTLAdjustment{{Record: source.records[1], From: 300 * time.Second, To: 600 * time.Second}}, report.TTLAdjustments)
+	assert.Len(t, api.Records("example.com"), 2)
+
+	report, err = provider.MigrateFrom(context.Background(), source, "example.com", "example.com", MigrationOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, report.Applied, 2)
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 3)
+	assert.Equal(t, "1.2.3.4", records[0]["ipv4"])
+	assert.Equal(t, "keep me", records[1]["text"])
+	assert.Equal(t, "www", records[2]["name"])
+	assert.Equal(t, float64(600), records[2]["ttl"])
+
+	//Repeating the migration changes nothing
+	report, err = provider.MigrateFrom(context.Background(), source, "example.com", "example.com", MigrationOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Changes)
+}
+
+func TestProvider_MigrateTo(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "NS", "ownername": "", "targetname": "ns1.hosttech.eu.", "ttl": 3600})
+
+	target := &memoryProvider{}
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+
+	report, err := provider.MigrateTo(context.Background(), "example.com", target, "example.com", MigrationOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, report.Applied, 1)
+	assert.Len(t, report.Skipped, 1)
+	assert.Equal(t, []libdns.Record{{ID: "1", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second}}, target.records)
+}
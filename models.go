@@ -3,14 +3,21 @@ package hosttech
 import (
 	"fmt"
 	"github.com/libdns/libdns"
+	"net"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// MinimalTTL is the minimal TTL accepted by the Hosttech API
+const MinimalTTL = 600 * time.Second
+
 // HosttechRecord must be implemented by each different type of record representation from the Hosttech.ch API, to allow a transformation from and to libdns.record.
 type HosttechRecord interface {
 	toLibdnsRecord(zone string) libdns.Record
-	fromLibdnsRecord(record libdns.Record) HosttechRecord
+	fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord
+	comment() string
+	validate(zone string) error
 }
 
 // Base holds all the values that are present in each record
@@ -21,6 +28,18 @@ type Base struct {
 	Comment string `json:"comment,omitempty"`
 }
 
+func (b Base) comment() string {
+	return b.Comment
+}
+
+// validateTTL checks the minimal TTL accepted by the API. A TTL of zero is not sent and lets the API pick its default.
+func (b Base) validateTTL() error {
+	if b.TTL != 0 && time.Duration(b.TTL)*time.Second < MinimalTTL {
+		return fmt.Errorf("TTL of %d seconds is below the minimum of %d seconds", b.TTL, int(MinimalTTL/time.Second))
+	}
+	return nil
+}
+
 // AAAARecord is an implementation of the AAAA record type
 type AAAARecord struct {
 	Base
@@ -38,16 +57,24 @@ func (a AAAARecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (a AAAARecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (a AAAARecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	a.Name = record.Name
 	a.Type = record.Type
 	a.IPV6 = record.Value
 	a.TTL = durationToIntSeconds(record.TTL)
-	a.Comment = generateComment()
+	a.Comment = comment
 
 	return a
 }
 
+func (a AAAARecord) validate(zone string) error {
+	ip := net.ParseIP(a.IPV6)
+	if ip == nil || ip.To4() != nil {
+		return fmt.Errorf(`"%s" is not a valid IPv6 address`, a.IPV6)
+	}
+	return a.validateTTL()
+}
+
 // ARecord is an implementation of the A record type
 type ARecord struct {
 	Base
@@ -65,16 +92,24 @@ func (a ARecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (a ARecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (a ARecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	a.Name = record.Name
 	a.Type = record.Type
 	a.IPV4 = record.Value
 	a.TTL = durationToIntSeconds(record.TTL)
-	a.Comment = generateComment()
+	a.Comment = comment
 
 	return a
 }
 
+func (a ARecord) validate(zone string) error {
+	ip := net.ParseIP(a.IPV4)
+	if ip == nil || ip.To4() == nil {
+		return fmt.Errorf(`"%s" is not a valid IPv4 address`, a.IPV4)
+	}
+	return a.validateTTL()
+}
+
 // CNAMERecord is an implementation of the CNAME record type
 type CNAMERecord struct {
 	Base
@@ -92,16 +127,26 @@ func (c CNAMERecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (c CNAMERecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (c CNAMERecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	c.Name = record.Name
 	c.Type = record.Type
 	c.Cname = record.Value
 	c.TTL = durationToIntSeconds(record.TTL)
-	c.Comment = generateComment()
+	c.Comment = comment
 
 	return c
 }
 
+func (c CNAMERecord) validate(zone string) error {
+	if NormalizeName(c.Name, zone) == "" {
+		return fmt.Errorf("a CNAME record is not allowed at the zone apex")
+	}
+	if c.Cname == "" {
+		return fmt.Errorf("a CNAME record needs a target")
+	}
+	return c.validateTTL()
+}
+
 // MXRecord is an implementation of the MX record type
 type MXRecord struct {
 	Base
@@ -117,21 +162,31 @@ func (m MXRecord) toLibdnsRecord(zone string) libdns.Record {
 		Name:     libdns.RelativeName(m.OwnerName, zone),
 		Value:    m.Name,
 		TTL:      time.Duration(m.TTL * 1000000000),
-		Priority: m.Pref,
+		Priority: uint(m.Pref),
 	}
 }
 
-func (m MXRecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (m MXRecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	m.OwnerName = record.Name
 	m.Type = record.Type
 	m.TTL = durationToIntSeconds(record.TTL)
 	m.Name = record.Value
-	m.Pref = record.Priority
-	m.Comment = generateComment()
+	m.Pref = int(record.Priority)
+	m.Comment = comment
 
 	return m
 }
 
+func (m MXRecord) validate(zone string) error {
+	if m.Pref < 0 || m.Pref > 65535 {
+		return fmt.Errorf("MX priority %d is not between 0 and 65535", m.Pref)
+	}
+	if m.Name == "" {
+		return fmt.Errorf("an MX record needs a mail server")
+	}
+	return m.validateTTL()
+}
+
 // NSRecord is an implementation of the NS record type
 type NSRecord struct {
 	Base
@@ -149,16 +204,23 @@ func (n NSRecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (n NSRecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (n NSRecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	n.OwnerName = record.Name
 	n.Type = record.Type
 	n.TargetName = record.Value
 	n.TTL = durationToIntSeconds(record.TTL)
-	n.Comment = generateComment()
+	n.Comment = comment
 
 	return n
 }
 
+func (n NSRecord) validate(zone string) error {
+	if n.TargetName == "" {
+		return fmt.Errorf("an NS record needs a name server")
+	}
+	return n.validateTTL()
+}
+
 // TXTRecord is an implementation of the TXT record type
 type TXTRecord struct {
 	Base
@@ -176,16 +238,20 @@ func (t TXTRecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (t TXTRecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (t TXTRecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	t.Name = record.Name
 	t.Type = record.Type
 	t.Text = record.Value
 	t.TTL = durationToIntSeconds(record.TTL)
-	t.Comment = generateComment()
+	t.Comment = comment
 
 	return t
 }
 
+func (t TXTRecord) validate(zone string) error {
+	return t.validateTTL()
+}
+
 // TLSARecord is an implementation of the TLSA record type
 type TLSARecord struct {
 	Base
@@ -203,16 +269,23 @@ func (t TLSARecord) toLibdnsRecord(zone string) libdns.Record {
 	}
 }
 
-func (t TLSARecord) fromLibdnsRecord(record libdns.Record) HosttechRecord {
+func (t TLSARecord) fromLibdnsRecord(record libdns.Record, comment string) HosttechRecord {
 	t.Name = record.Name
 	t.Type = record.Type
 	t.Text = record.Value
 	t.TTL = durationToIntSeconds(record.TTL)
-	t.Comment = generateComment()
+	t.Comment = comment
 
 	return t
 }
 
+func (t TLSARecord) validate(zone string) error {
+	if len(strings.Fields(t.Text)) != 4 {
+		return fmt.Errorf(`"%s" is not a valid TLSA value, expected usage, selector, matching type and data`, t.Text)
+	}
+	return t.validateTTL()
+}
+
 func durationToIntSeconds(duration time.Duration) int {
 	return int(duration.Seconds())
 }
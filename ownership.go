@@ -0,0 +1,151 @@
+package hosttech
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// The heritage marks a comment as ownership metadata written by this library
+const ownershipHeritage = "libdns"
+
+// The keys of the ownership metadata that can not be used in Extra
+var reservedOwnershipKeys = map[string]bool{"heritage": true, "owner": true, "tool": true}
+
+// Ownership describes which system manages the records written by the provider. It is encoded into the comment of
+// every record the provider creates or updates, similar to the TXT registry of external-dns.
+type Ownership struct {
+	// OwnerID identifies the owner, e.g. a cluster or a team
+	OwnerID string `json:"owner_id,omitempty"`
+	// Tool names the tool that wrote the record
+	Tool string `json:"tool,omitempty"`
+	// Extra holds additional key/value pairs to store with the record
+	Extra map[string]string `json:"extra,omitempty"`
+	// Enforce makes SetRecords and DeleteRecords refuse to modify records that are not owned by OwnerID
+	Enforce bool `json:"enforce,omitempty"`
+}
+
+// String encodes the ownership metadata in the format that is stored in the record comment,
+// e.g. "heritage=libdns,owner=team-a,tool=certmagic,env=prod". Extra keys named like the reserved keys heritage,
+// owner and tool are left out, so they can not override the owner.
+func (o Ownership) String() string {
+	pairs := []string{
+		"heritage=" + ownershipHeritage,
+		"owner=" + url.QueryEscape(o.OwnerID),
+	}
+	if o.Tool != "" {
+		pairs = append(pairs, "tool="+url.QueryEscape(o.Tool))
+	}
+
+	var keys []string
+	for key := range o.Extra {
+		if !reservedOwnershipKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(o.Extra[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// OwnsComment reports whether a record comment carries ownership metadata with the same owner.
+func (o Ownership) OwnsComment(comment string) bool {
+	owner, ok := ParseOwnership(comment)
+	return ok && owner.OwnerID == o.OwnerID
+}
+
+// ParseOwnership decodes the ownership metadata of a record comment.
+// It returns false if the comment was not written by a provider with ownership configured.
+// Only the first occurrence of a reserved key counts, later ones are ignored.
+func ParseOwnership(comment string) (Ownership, bool) {
+	ownership := Ownership{}
+	heritage := false
+	seen := map[string]bool{}
+	for _, pair := range strings.Split(comment, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return Ownership{}, false
+		}
+
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return Ownership{}, false
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return Ownership{}, false
+		}
+
+		if reservedOwnershipKeys[key] {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		switch key {
+		case "heritage":
+			heritage = value == ownershipHeritage
+		case "owner":
+			ownership.OwnerID = value
+		case "tool":
+			ownership.Tool = value
+		default:
+			if ownership.Extra == nil {
+				ownership.Extra = map[string]string{}
+			}
+			ownership.Extra[key] = value
+		}
+	}
+
+	if !heritage {
+		return Ownership{}, false
+	}
+
+	return ownership, true
+}
+
+// OwnershipError is returned when a record would be modified that is not owned by the configured owner.
+type OwnershipError struct {
+	RecordID string
+	// Comment is the comment of the record in the zone
+	Comment string
+}
+
+func (o OwnershipError) Error() string {
+	owner, ok := ParseOwnership(o.Comment)
+	if !ok {
+		return fmt.Sprintf("record '%s' is not managed by libdns and will not be modified", o.RecordID)
+	}
+	return fmt.Sprintf("record '%s' is owned by '%s' and will not be modified", o.RecordID, owner.OwnerID)
+}
+
+// checkOwnership makes sure all records that exist in the zone are owned by the configured owner.
+// Records that do not exist in the zone yet are not checked.
+func (p *Provider) checkOwnership(ctx context.Context, zone string, recordIds []string) error {
+	if p.Ownership == nil || !p.Ownership.Enforce {
+		return nil
+	}
+
+	comments, err := p.getRecordComments(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	for _, recordId := range recordIds {
+		comment, exists := comments[recordId]
+		if exists && !p.Ownership.OwnsComment(comment) {
+			return OwnershipError{
+				RecordID: recordId,
+				Comment:  comment,
+			}
+		}
+	}
+
+	return nil
+}
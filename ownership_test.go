@@ -0,0 +1,77 @@
+package hosttech
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOwnership(t *testing.T) {
+	input := map[string]struct {
+		expectedResult Ownership
+		expectedOk     bool
+		data           string
+	}{
+		"Full Ownership Test": {
+			expectedResult: Ownership{
+				OwnerID: "team a",
+				Tool:    "certmagic",
+				Extra:   map[string]string{"env": "prod", "ticket": "OPS-1,2"},
+			},
+			expectedOk: true,
+			data:       "heritage=libdns,owner=team+a,tool=certmagic,env=prod,ticket=OPS-1%2C2",
+		},
+		"Owner Only Test": {
+			expectedResult: Ownership{OwnerID: "default"},
+			expectedOk:     true,
+			data:           "heritage=libdns,owner=default",
+		},
+		"Repeated Reserved Key Test": {
+			expectedResult: Ownership{OwnerID: "team a", Tool: "certmagic"},
+			expectedOk:     true,
+			data:           "heritage=libdns,owner=team+a,tool=certmagic,owner=team+b,tool=other,heritage=external-dns",
+		},
+		"Foreign Heritage Test": {
+			expectedResult: Ownership{},
+			expectedOk:     false,
+			data:           "heritage=external-dns,owner=default",
+		},
+		"Generic Comment Test": {
+			expectedResult: Ownership{},
+			expectedOk:     false,
+			data:           generateComment(),
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			output, ok := ParseOwnership(testStruct.data)
+
+			assert.Equal(t, testStruct.expectedOk, ok)
+			assert.Equal(t, testStruct.expectedResult, output)
+		})
+	}
+}
+
+func TestOwnership_String(t *testing.T) {
+	ownership := Ownership{
+		OwnerID: "team a",
+		Tool:    "certmagic",
+		Extra:   map[string]string{"ticket": "OPS-1,2", "env": "prod"},
+	}
+
+	assert.Equal(t, "heritage=libdns,owner=team+a,tool=certmagic,env=prod,ticket=OPS-1%2C2", ownership.String())
+	assert.True(t, ownership.OwnsComment(ownership.String()))
+	assert.False(t, Ownership{OwnerID: "team b"}.OwnsComment(ownership.String()))
+}
+
+func TestOwnership_StringReservedKeys(t *testing.T) {
+	ownership := Ownership{
+		OwnerID: "team a",
+		Extra:   map[string]string{"owner": "team b", "heritage": "external-dns", "tool": "other", "env": "prod"},
+	}
+
+	assert.Equal(t, "heritage=libdns,owner=team+a,env=prod", ownership.String())
+	assert.True(t, ownership.OwnsComment(ownership.String()))
+	assert.False(t, Ownership{OwnerID: "team b"}.OwnsComment(ownership.String()))
+}
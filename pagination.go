@@ -0,0 +1,93 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// pagination holds the links and metadata of a paginated list response. Responses of lists that fit on one page
+// carry neither.
+type pagination struct {
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Meta struct {
+		CurrentPage int `json:"current_page"`
+		LastPage    int `json:"last_page"`
+	} `json:"meta"`
+}
+
+// getPages requests the list and all of its following pages, passing the body of every page to handlePage.
+func (p *Provider) getPages(ctx context.Context, reqURL string, zone string, handlePage func(responseBody []byte) error) error {
+	visited := map[string]bool{}
+	for reqURL != "" {
+		//A page linking back to an earlier one would never end
+		if visited[reqURL] {
+			return fmt.Errorf("pagination of '%s' loops", apiPath(reqURL))
+		}
+		visited[reqURL] = true
+
+		responseBody, err := p.makeApiCall(ctx, http.MethodGet, reqURL, nil, zone)
+		if err != nil {
+			return err
+		}
+
+		err = handlePage(responseBody)
+		if err != nil {
+			return err
+		}
+
+		reqURL, err = nextPage(p.baseURL(), reqURL, responseBody)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextPage returns the URL of the page following the response, or an empty string if it was the last page.
+// The next link is preferred; without it, the page number is taken from the metadata. A next link pointing away from
+// the API is rejected, because the API token is sent along with the request.
+func nextPage(baseURL string, reqURL string, responseBody []byte) (string, error) {
+	var page pagination
+	err := json.Unmarshal(responseBody, &page)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := url.Parse(reqURL)
+	if err != nil {
+		return "", err
+	}
+
+	if page.Links.Next != "" {
+		next, err := current.Parse(page.Links.Next)
+		if err != nil {
+			return "", err
+		}
+
+		base, err := url.Parse(baseURL)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(next.Scheme, base.Scheme) || !strings.EqualFold(next.Host, base.Host) {
+			return "", fmt.Errorf("next page '%s' is not on the API at '%s'", next.Redacted(), base.Host)
+		}
+		return next.String(), nil
+	}
+
+	if page.Meta.CurrentPage > 0 && page.Meta.CurrentPage < page.Meta.LastPage {
+		query := current.Query()
+		query.Set("page", strconv.Itoa(page.Meta.CurrentPage+1))
+		current.RawQuery = query.Encode()
+		return current.String(), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,102 @@
+package hosttech
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Pagination(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com", "example.org", "example.net")
+	defer api.Close()
+	api.PageSize = 2
+	for i := range 5 {
+		api.AddRecord("example.com", map[string]any{"type": "A", "name": fmt.Sprintf("host%d", i), "ipv4": "1.2.3.4", "ttl": 3600})
+	}
+	reads := countReads(api)
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	records, err := provider.GetRecords(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, records, 5)
+	assert.Equal(t, "host4", records[4].Name)
+	assert.EqualValues(t, 3, reads.Load())
+
+	zones, err := provider.ListZones(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []libdns.Zone{{Name: "example.com"}, {Name: "example.net"}, {Name: "example.org"}}, zones)
+}
+
+func TestNextPage(t *testing.T) {
+	input := map[string]struct {
+		url         string
+		body        string
+		expected    string
+		expectedErr bool
+	}{
+		"Single Page Test": {
+			url:      "https://api.example.com/zones",
+			body:     `{"data": []}`,
+			expected: "",
+		},
+		"Next Link Test": {
+			url:      "https://api.example.com/zones",
+			body:     `{"data": [], "links": {"next": "https://api.example.com/zones?page=2"}}`,
+			expected: "https://api.example.com/zones?page=2",
+		},
+		"Relative Next Link Test": {
+			url:      "https://api.example.com/zones/example.com/records?type=A",
+			body:     `{"data": [], "links": {"next": "?type=A&page=2"}}`,
+			expected: "https://api.example.com/zones/example.com/records?type=A&page=2",
+		},
+		"Other Host Test": {
+			url:         "https://api.example.com/zones",
+			body:        `{"data": [], "links": {"next": "https://evil.example.net/zones?page=2"}}`,
+			expectedErr: true,
+		},
+		"Other Scheme Test": {
+			url:         "https://api.example.com/zones",
+			body:        `{"data": [], "links": {"next": "http://api.example.com/zones?page=2"}}`,
+			expectedErr: true,
+		},
+		"Last Page Test": {
+			url:      "https://api.example.com/zones?page=2",
+			body:     `{"data": [], "links": {"next": null}, "meta": {"current_page": 2, "last_page": 2}}`,
+			expected: "",
+		},
+		"Meta Only Test": {
+			url:      "https://api.example.com/zones/example.com/records?type=A",
+			body:     `{"data": [], "meta": {"current_page": 1, "last_page": 3}}`,
+			expected: "https://api.example.com/zones/example.com/records?page=2&type=A",
+		},
+	}
+
+	for desc, tc := range input {
+		t.Run(desc, func(t *testing.T) {
+			next, err := nextPage("https://api.example.com", tc.url, []byte(tc.body))
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, next)
+		})
+	}
+}
+
+func TestProvider_PaginationLoop(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [], "links": {"next": "/zones"}}`)
+	}))
+	defer api.Close()
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	_, err := provider.ListZones(context.Background())
+	assert.EqualError(t, err, "pagination of '/zones' loops")
+}
@@ -6,59 +6,201 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// Provider facilitates DNS record manipulation with Hosttech.ch.
+// Provider facilitates DNS record manipulation with Hosttech.ch. It is safe for concurrent use, changes to the same zone
+// are serialized. Its fields must not be modified once it is in use.
 type Provider struct {
 	APIToken string `json:"api_token,omitempty"`
+
+	// APIURL overrides the URL of the Hosttech API, e.g. to point the provider at a test server.
+	APIURL string `json:"api_url,omitempty"`
+
+	// DryRun performs all reads and validation, but records and logs the POST, PUT and DELETE requests
+	// instead of sending them. The recorded requests are available with DryRunOperations.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Ownership is encoded into the comment of every record written by the provider. If it is enforced,
+	// records owned by someone else are neither updated nor deleted.
+	Ownership *Ownership `json:"ownership,omitempty"`
+
+	// CommentMode controls whether comments are written, preserved or left out. Defaults to writing a new comment.
+	CommentMode CommentMode `json:"comment_mode,omitempty"`
+
+	// CommentTemplate is a text/template that replaces the default comment, e.g. "managed by {{.Zone}} tooling".
+	// It is executed with CommentData.
+	CommentTemplate string `json:"comment_template,omitempty"`
+
+	// TTLPolicy controls whether records with a TTL below the minimum of the API are rejected or clamped.
+	// Defaults to rejecting them.
+	TTLPolicy TTLPolicy `json:"ttl_policy,omitempty"`
+
+	// Journal records every change made by the provider to a local file, so it can be audited and undone.
+	Journal *Journal `json:"journal,omitempty"`
+
+	// Logger receives a structured entry for every API call. Without it, API calls are not logged.
+	Logger *slog.Logger `json:"-"`
+
+	// LogVerbosity controls which API calls are logged and in how much detail. Defaults to failed calls only.
+	LogVerbosity LogVerbosity `json:"log_verbosity,omitempty"`
+
+	// TracerProvider creates a span for every public method and a child span for every API request.
+	// Defaults to the global provider of OpenTelemetry, which does nothing unless the application configures it.
+	TracerProvider trace.TracerProvider `json:"-"`
+
+	// MeterProvider records the number, duration and errors of API requests. Defaults to the global provider of
+	// OpenTelemetry, which does nothing unless the application configures it.
+	MeterProvider metric.MeterProvider `json:"-"`
+
+	// MaxRetries is the number of times a read is sent again if it got no response or failed with the status 429 or
+	// 5xx. Writes are never retried, as the API may have applied them already. Defaults to no retries.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	// RetryDelay is the delay before the first retry, doubling with every further retry up to 30 seconds.
+	// Defaults to one second.
+	RetryDelay time.Duration `json:"retry_delay,omitempty"`
+
+	// CacheTTL enables an in-memory cache of the records of each zone. GetRecords and GetRecordsWithComments are served
+	// from the cache for this long after the zone was read, and concurrent reads of the same zone share one request.
+	// Writes of the provider update the cache. Changes made elsewhere show up once the cache expires or is cleared
+	// with ClearCache. Defaults to no caching.
+	CacheTTL time.Duration `json:"cache_ttl,omitempty"`
+
+	// CheckConflicts makes SetRecords, Restore and Undo fail with a ConflictError instead of overwriting a record that
+	// changed at Hosttech, including its comment, since it was last returned by GetRecords, GetRecordsFiltered or Plan,
+	// or written by the provider. Records the provider has not returned yet are updated without a check.
+	CheckConflicts bool `json:"check_conflicts,omitempty"`
+
+	// LockDir enables a lock file per zone in the directory, so that processes on the same host sharing the directory
+	// do not interleave their changes to a zone. Within a process, changes to a zone are always serialized.
+	// Lock files are only supported on Unix.
+	LockDir string `json:"lock_dir,omitempty"`
+
+	// DryRunLogger receives a line for every request recorded in dry-run mode. Defaults to the standard logger.
+	DryRunLogger *log.Logger `json:"-"`
+
+	telemetryOnce  sync.Once
+	telemetryState *telemetry
+
+	zoneLocksMu sync.Mutex
+	zoneLocks   map[string]chan struct{}
+
+	seenMu      sync.Mutex
+	seenRecords map[string]map[string]RecordWithComment
+
+	cacheMu    sync.Mutex
+	cacheZones map[string]*cachedZone
+
+	dryRunMu         sync.Mutex
+	dryRunOperations []Operation
 }
 
 // The URL for the Hosttech API connection
 const apiHost = "https://api.ns1.hosttech.eu/api/user/v1"
 
-// GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	reqURL := fmt.Sprintf("%s/zones/%s/records", apiHost, zone)
+func (p *Provider) baseURL() string {
+	if p.APIURL != "" {
+		return p.APIURL
+	}
+	return apiHost
+}
 
-	responseBody, err := p.makeApiCall(ctx, http.MethodGet, reqURL, nil, zone)
+// zoneKey is the key the state of the provider is kept under for the zone, the same for all spellings of its name.
+func zoneKey(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
 
-	//If there's an error return an empty slice
-	if err != nil {
-		return []libdns.Record{}, err
-	}
+// GetRecords lists all the records in the zone.
+func (p *Provider) GetRecords(ctx context.Context, zone string) (_ []libdns.Record, err error) {
+	ctx, endSpan := p.startSpan(ctx, "GetRecords", zone, nil)
+	defer endSpan(&err)
 
-	var parsedResponse = HosttechListResponseWrapper{}
-	err = json.Unmarshal(responseBody, &parsedResponse)
+	hosttechRecords, err := p.getCachedHosttechRecords(ctx, zone)
 
+	//If there's an error return an empty slice
 	if err != nil {
 		return []libdns.Record{}, err
 	}
+	p.rememberRecords(zone, hosttechRecords, true)
 
 	var libdnsRecords []libdns.Record
-	for _, record := range parsedResponse.Data {
+	for _, record := range hosttechRecords {
 		libdnsRecords = append(libdnsRecords, record.toLibdnsRecord(zone))
 	}
 
 	return libdnsRecords, nil
 }
 
+// ListZones lists all zones of the account.
+func (p *Provider) ListZones(ctx context.Context) (_ []libdns.Zone, err error) {
+	ctx, endSpan := p.startSpan(ctx, "ListZones", "", nil)
+	defer endSpan(&err)
+
+	zones := []libdns.Zone{}
+	err = p.getPages(ctx, p.baseURL()+"/zones", "", func(responseBody []byte) error {
+		var parsedResponse struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		}
+		err := json.Unmarshal(responseBody, &parsedResponse)
+		if err != nil {
+			return err
+		}
+
+		for _, zone := range parsedResponse.Data {
+			zones = append(zones, libdns.Zone{Name: zone.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
 // AppendRecords adds records to the zone. It returns all records that were added.
 // If an error occurs while records are being added, the already successfully added records will be returned along with an error.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	reqURL := fmt.Sprintf("%s/zones/%s/records", apiHost, zone)
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, endSpan := p.startSpan(ctx, "AppendRecords", zone, records)
+	defer endSpan(&err)
 
-	successfullyAppendedRecords := []libdns.Record{}
-	for _, record := range records {
+	ctx, unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-		hosttechRecord, err := LibdnsRecordToHosttechRecordWrapper(record)
-		if err != nil {
-			return nil, err
-		}
+	appendedRecords, err := p.appendRecords(ctx, zone, records)
+	return appendedRecords, p.journalChange(zone, JournalAppend, nil, appendedRecords, err)
+}
+
+func (p *Provider) appendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	reqURL := fmt.Sprintf("%s/zones/%s/records", p.baseURL(), zone)
+
+	//Validate the whole batch before anything is sent
+	hosttechRecords, err := p.toHosttechRecords(zone, records, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	successfullyAppendedRecords := []libdns.Record{}
+	for _, hosttechRecord := range hosttechRecords {
 
 		bodyBytes, err := json.Marshal(hosttechRecord)
 		if err != nil {
@@ -85,21 +227,66 @@ func (p *Provider) AppendRecords(ctx context.Context, zone string, records []lib
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
 // It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	successfullyUpdatedRecords := []libdns.Record{}
-	for _, record := range records {
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, endSpan := p.startSpan(ctx, "SetRecords", zone, records)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	ctx = p.shareZoneReads(ctx, zone)
+
+	before, err := p.journalState(ctx, zone, recordIds(records))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedRecords, err := p.setRecords(ctx, zone, records)
+	return updatedRecords, p.journalChange(zone, JournalSet, before, updatedRecords, err)
+}
+
+func (p *Provider) setRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	//Validate the whole batch before anything is sent
+	err := p.ValidateRecords(zone, records)
+	if err != nil {
+		return nil, err
+	}
 
-		hosttechRecord, err := LibdnsRecordToHosttechRecordWrapper(record)
+	err = p.checkOwnership(ctx, zone, recordIds(records))
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.checkConflicts(ctx, zone, recordIds(records))
+	if err != nil {
+		return nil, err
+	}
+
+	var existingComments map[string]string
+	if p.CommentMode == CommentPreserve {
+		existingComments, err = p.getRecordComments(ctx, zone)
 		if err != nil {
 			return nil, err
 		}
-		bodyBytes, err := json.Marshal(hosttechRecord)
+	}
+
+	hosttechRecords, err := p.toHosttechRecords(zone, records, existingComments)
+	if err != nil {
+		return nil, err
+	}
+
+	successfullyUpdatedRecords := []libdns.Record{}
+	for i, record := range records {
+
+		bodyBytes, err := json.Marshal(hosttechRecords[i])
 
 		if err != nil {
 			return nil, err
 		}
 
-		reqURL := fmt.Sprintf("%s/zones/%s/records/%s", apiHost, zone, record.ID)
+		reqURL := fmt.Sprintf("%s/zones/%s/records/%s", p.baseURL(), zone, record.ID)
 
 		responseBody, err := p.makeApiCall(ctx, http.MethodPut, reqURL, bytes.NewReader(bodyBytes), zone)
 
@@ -116,7 +303,7 @@ func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns
 			}
 
 			//If the error was a 404, the record could not be updated because it didn't exist. So we create a new one
-			appendedRecords, err := p.AppendRecords(ctx, zone, []libdns.Record{record})
+			appendedRecords, err := p.appendRecords(ctx, zone, []libdns.Record{record})
 			if err != nil {
 				return successfullyUpdatedRecords, err
 			}
@@ -139,10 +326,35 @@ func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns
 
 // DeleteRecords deletes the records from the zone. It returns the records that were deleted.
 // If an error occurs while records are being deleted, the already successfully deleted records will be returned along with an error.
-func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, endSpan := p.startSpan(ctx, "DeleteRecords", zone, records)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	ctx = p.shareZoneReads(ctx, zone)
+
+	before, err := p.journalState(ctx, zone, recordIds(records))
+	if err != nil {
+		return nil, err
+	}
+
+	deletedRecords, err := p.deleteRecords(ctx, zone, records)
+	return deletedRecords, p.journalChange(zone, JournalDelete, before, deletedRecords, err)
+}
+
+func (p *Provider) deleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	err := p.checkOwnership(ctx, zone, recordIds(records))
+	if err != nil {
+		return nil, err
+	}
+
 	successfullyDeletedRecords := []libdns.Record{}
 	for _, record := range records {
-		reqUrl := fmt.Sprintf("%s/zones/%s/records/%s", apiHost, zone, record.ID)
+		reqUrl := fmt.Sprintf("%s/zones/%s/records/%s", p.baseURL(), zone, record.ID)
 		_, err := p.makeApiCall(ctx, http.MethodDelete, reqUrl, nil, zone)
 
 		if err != nil {
@@ -155,16 +367,116 @@ func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []lib
 	return successfullyDeletedRecords, nil
 }
 
-func (p *Provider) makeApiCall(ctx context.Context, httpMethod string, reqUrl string, body io.Reader, zone string) (response []byte, err error) {
+// listHosttechRecords lists the records in the zone matching the query, following the pages of the response.
+func (p *Provider) listHosttechRecords(ctx context.Context, zone string, query url.Values) ([]HosttechRecordWrapper, error) {
+	reqURL := fmt.Sprintf("%s/zones/%s/records", p.baseURL(), zone)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	records := []HosttechRecordWrapper{}
+	err := p.getPages(ctx, reqURL, zone, func(responseBody []byte) error {
+		var parsedResponse = HosttechListResponseWrapper{}
+		err := json.Unmarshal(responseBody, &parsedResponse)
+		if err != nil {
+			return err
+		}
+
+		records = append(records, parsedResponse.Data...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func recordIds(records []libdns.Record) []string {
+	var ids []string
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func (p *Provider) makeApiCall(ctx context.Context, httpMethod string, reqUrl string, body io.Reader, zone string) ([]byte, error) {
+	//Bodies are small, reading them upfront lets logging and tracing look at the record type and retries resend them
+	var requestBody []byte
+	if body != nil {
+		var err error
+		requestBody, err = io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		var requestReader io.Reader
+		if requestBody != nil {
+			requestReader = bytes.NewReader(requestBody)
+		}
+
+		call := apiCall{
+			method:      httpMethod,
+			url:         reqUrl,
+			zone:        zone,
+			attempt:     attempt,
+			requestBody: requestBody,
+		}
+		callCtx, endSpan := p.startApiCallSpan(ctx, call)
+
+		start := time.Now()
+		response, status, err := p.sendApiCall(callCtx, httpMethod, reqUrl, requestReader, zone)
+		call.status = status
+		call.latency = time.Since(start)
+		call.responseBody = response
+		call.err = err
+
+		endSpan(call)
+		if p.Logger != nil {
+			p.logApiCall(callCtx, call)
+		}
+
+		if attempt > p.MaxRetries || !retryable(httpMethod, status, err) {
+			p.updateCache(zone, httpMethod, reqUrl, response, err)
+			p.rememberWrite(zone, httpMethod, reqUrl, response, err)
+			p.forgetZoneReads(ctx, zone, httpMethod)
+			return response, err
+		}
+
+		delay := p.retryDelay(attempt)
+		p.recordRetry(ctx, call)
+		if p.Logger != nil {
+			p.logRetry(ctx, call, attempt, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return response, errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// sendApiCall sends the request and returns the response body and the status code. The status code is zero if the
+// request was recorded in dry-run mode or could not be sent.
+func (p *Provider) sendApiCall(ctx context.Context, httpMethod string, reqUrl string, body io.Reader, zone string) (response []byte, status int, err error) {
+	if p.DryRun && httpMethod != http.MethodGet {
+		response, err = p.dryRunApiCall(ctx, httpMethod, reqUrl, body, zone)
+		return
+	}
+
 	req, err := http.NewRequestWithContext(ctx, httpMethod, reqUrl, body)
-	req.Header.Set("Authorization", "Bearer "+p.APIToken)
-	req.Header.Set("Content-Type", "application/json")
 
 	//Return nil if there's an error
 	if err != nil {
 		return
 	}
 
+	req.Header.Set("Authorization", "Bearer "+p.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
 	resp, err := http.DefaultClient.Do(req)
 
 	//Return an empty slice if there's an error
@@ -172,14 +484,18 @@ func (p *Provider) makeApiCall(ctx context.Context, httpMethod string, reqUrl st
 		return
 	}
 
+	defer resp.Body.Close()
+	status = resp.StatusCode
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, ApiError{
+		return nil, status, ApiError{
 			s:         fmt.Sprintf("call to API was not successful, returned the status code '%s'", resp.Status),
 			ErrorCode: resp.StatusCode,
 		}
 	}
 
-	return io.ReadAll(resp.Body)
+	response, err = io.ReadAll(resp.Body)
+	return
 
 	/*
 		err = json.NewDecoder(resp.Body).Decode(response)
@@ -201,4 +517,5 @@ var (
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
@@ -0,0 +1,70 @@
+package hosttech
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// zoneReadsKey marks a context in which the records of the zone are read from the API at most once, shared by the
+// journal, ownership, conflict and comment lookups and the existence checks of dry-run mode.
+type zoneReadsKey struct {
+	provider *Provider
+	zone     string
+}
+
+// zoneReads holds the records of a zone read within a call, until the call changes the zone.
+type zoneReads struct {
+	mu      sync.Mutex
+	records []HosttechRecordWrapper
+}
+
+// shareZoneReads returns a context in which the records of the zone are read at most once until the zone is changed.
+// Nested calls keep sharing the reads of the outer call.
+func (p *Provider) shareZoneReads(ctx context.Context, zone string) context.Context {
+	key := zoneReadsKey{p, zoneKey(zone)}
+	if ctx.Value(key) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, key, &zoneReads{})
+}
+
+// getHosttechRecords lists all the records in the zone as they are returned by the API, including their comments.
+// Within a call that shares its reads, the zone is only read once.
+func (p *Provider) getHosttechRecords(ctx context.Context, zone string) ([]HosttechRecordWrapper, error) {
+	reads, _ := ctx.Value(zoneReadsKey{p, zoneKey(zone)}).(*zoneReads)
+	if reads == nil {
+		return p.listHosttechRecords(ctx, zone, nil)
+	}
+
+	reads.mu.Lock()
+	defer reads.mu.Unlock()
+
+	if reads.records == nil {
+		records, err := p.listHosttechRecords(ctx, zone, nil)
+		if err != nil {
+			return nil, err
+		}
+		reads.records = records
+	}
+
+	return slices.Clone(reads.records), nil
+}
+
+// forgetZoneReads drops the shared records of the zone after a mutating API call, because the zone may have changed.
+// Recorded requests in dry-run mode change nothing.
+func (p *Provider) forgetZoneReads(ctx context.Context, zone string, httpMethod string) {
+	if p.DryRun || httpMethod == http.MethodGet {
+		return
+	}
+
+	reads, _ := ctx.Value(zoneReadsKey{p, zoneKey(zone)}).(*zoneReads)
+	if reads == nil {
+		return
+	}
+
+	reads.mu.Lock()
+	defer reads.mu.Unlock()
+	reads.records = nil
+}
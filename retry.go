@@ -0,0 +1,62 @@
+package hosttech
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// The delay before the first retry if RetryDelay is not set, and the upper bound of the growing delays
+const (
+	defaultRetryDelay = time.Second
+	maximalRetryDelay = 30 * time.Second
+)
+
+// retryable reports whether a failed request may be sent again. Only reads are retried, if they got no response or
+// failed with the status 429 or 5xx. A write may have been applied by the API before it failed, sending it again could
+// create a record twice or fail on a record that is already gone.
+func retryable(httpMethod string, status int, err error) bool {
+	if httpMethod != http.MethodGet || err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+
+	//Only errors of the HTTP client mean that no response was received, other errors would just repeat. A URL that
+	//cannot be parsed fails with an url.Error as well, but before anything is sent.
+	var urlError *url.Error
+	if status == 0 {
+		return errors.As(err, &urlError) && urlError.Op != "parse"
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// retryDelay returns the delay before the retry with the number, starting at 1. The delay doubles with every retry.
+func (p *Provider) retryDelay(retry int) time.Duration {
+	delay := p.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	for i := 1; i < retry && delay < maximalRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maximalRetryDelay)
+}
+
+// logRetry writes an entry for a request that is sent again after the delay. Retries are logged at every verbosity.
+func (p *Provider) logRetry(ctx context.Context, call apiCall, retry int, delay time.Duration) {
+	p.Logger.LogAttrs(ctx, slog.LevelWarn, "retrying hosttech API call",
+		slog.String("method", call.method),
+		slog.String("path", p.redact(apiPath(call.url))),
+		slog.String("zone", call.zone),
+		slog.Int("status", call.status),
+		slog.Int("attempt", retry+1),
+		slog.Duration("delay", delay),
+	)
+}
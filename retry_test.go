@@ -0,0 +1,117 @@
+package hosttech
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_Retries(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+
+	//Every request fails with the status until failures is used up
+	var failures atomic.Int32
+	var status atomic.Int32
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(int(status.Load()))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	var output bytes.Buffer
+	provider := Provider{
+		APIToken:   "token",
+		APIURL:     api.URL,
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+		Logger:     slog.New(slog.NewTextHandler(&output, nil)),
+	}
+	ctx := context.Background()
+	record := libdns.Record{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second}
+
+	failures.Store(2)
+	status.Store(http.StatusServiceUnavailable)
+	_, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), `msg="retrying hosttech API call" method=GET path=/zones/example.com/records zone=example.com status=503 attempt=2 delay=1ms`)
+	assert.Contains(t, output.String(), "attempt=3 delay=2ms")
+
+	failures.Store(3)
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.ErrorAs(t, err, &ApiError{})
+	failures.Store(0)
+
+	failures.Store(1)
+	status.Store(http.StatusTooManyRequests)
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+
+	//Writes are not retried, the API might have applied them already
+	output.Reset()
+	failures.Store(1)
+	status.Store(http.StatusServiceUnavailable)
+	_, err = provider.AppendRecords(ctx, "example.com", []libdns.Record{record})
+	assert.Error(t, err)
+	assert.Empty(t, api.Records("example.com"))
+
+	appended, err := provider.AppendRecords(ctx, "example.com", []libdns.Record{record})
+	assert.NoError(t, err)
+
+	failures.Store(1)
+	status.Store(http.StatusTooManyRequests)
+	_, err = provider.DeleteRecords(ctx, "example.com", appended)
+	assert.Error(t, err)
+	assert.Len(t, api.Records("example.com"), 1)
+	assert.NotContains(t, output.String(), "retrying")
+
+	//Without retries configured, nothing is sent again
+	output.Reset()
+	failures.Store(1)
+	provider.MaxRetries = 0
+	_, err = provider.GetRecords(ctx, "example.com")
+	assert.Error(t, err)
+	assert.NotContains(t, output.String(), "retrying")
+}
+
+func TestProvider_RetryCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	provider := Provider{APIToken: "token", APIURL: server.URL, MaxRetries: 5, RetryDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := provider.GetRecords(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, strings.Contains(err.Error(), "502"))
+}
+
+func TestProvider_MalformedURL(t *testing.T) {
+	provider := Provider{APIToken: "token", APIURL: "://example.com", MaxRetries: 5, RetryDelay: time.Hour}
+
+	_, err := provider.GetRecords(context.Background(), "example.com")
+	assert.ErrorContains(t, err, "missing protocol scheme")
+}
+
+func TestProvider_RetryDelay(t *testing.T) {
+	provider := Provider{}
+	assert.Equal(t, time.Second, provider.retryDelay(1))
+	assert.Equal(t, 4*time.Second, provider.retryDelay(3))
+	assert.Equal(t, 30*time.Second, provider.retryDelay(10))
+}
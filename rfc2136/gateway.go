@@ -0,0 +1,448 @@
+// Package rfc2136 implements a DNS server that accepts RFC 2136 dynamic updates and applies them to Hosttech.ch
+// through the libdns provider, for clients that only speak DNS UPDATE.
+package rfc2136
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// Provider reads the zone to check the prerequisites of an update, and applies it as deletions and creations. Hosttech
+// has no RRsets, so there is no need to set records.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordDeleter
+}
+
+// Gateway handles UPDATE messages for a set of zones. Only messages signed with one of the TSIG keys of the
+// dns.Server serving the gateway are accepted.
+type Gateway struct {
+	Provider Provider
+
+	// Zones are the zones that may be updated
+	Zones []string
+
+	// KeyZones restricts TSIG keys to some of the Zones, by the name of the key. If it is set, keys that are not
+	// listed are refused. Without it, every key may update every zone.
+	KeyZones map[string][]string
+
+	// Timeout limits the time spent on the API for a single update. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// Logger receives a line for every rejected or failed update, with the reason the RCODE of the response leaves
+	// out. If it is nil, log.Default() is used.
+	Logger *log.Logger
+}
+
+// AcceptUpdates is the dns.MsgAcceptFunc for servers of a gateway. The default of miekg/dns rejects UPDATE
+// messages, as their sections may contain any number of records.
+func AcceptUpdates(dh dns.Header) dns.MsgAcceptAction {
+	if isResponse := dh.Bits&(1<<15) != 0; isResponse {
+		return dns.MsgIgnore
+	}
+
+	opcode := int(dh.Bits>>11) & 0xF
+	if opcode != dns.OpcodeUpdate {
+		return dns.MsgRejectNotImplemented
+	}
+
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+	return dns.MsgAccept
+}
+
+// ServeDNS implements dns.Handler.
+func (g *Gateway) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	response := new(dns.Msg)
+	response.SetRcode(r, g.handle(w, r))
+
+	//Sign the response with the key of the request
+	if tsig := r.IsTsig(); tsig != nil && w.TsigStatus() == nil {
+		response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+
+	w.WriteMsg(response)
+}
+
+func (g *Gateway) handle(w dns.ResponseWriter, r *dns.Msg) int {
+	if r.Opcode != dns.OpcodeUpdate {
+		return dns.RcodeNotImplemented
+	}
+
+	if r.IsTsig() == nil {
+		g.logger().Printf("rejected unsigned update from %s", w.RemoteAddr())
+		return dns.RcodeRefused
+	}
+	if err := w.TsigStatus(); err != nil {
+		g.logger().Printf("rejected update from %s: %s", w.RemoteAddr(), err)
+		return dns.RcodeNotAuth
+	}
+
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA {
+		return dns.RcodeFormatError
+	}
+
+	zone := g.zone(r.Question[0].Name)
+	if zone == "" {
+		return dns.RcodeNotAuth
+	}
+
+	keyName := r.IsTsig().Hdr.Name
+	if !g.keyMayUpdate(keyName, zone) {
+		g.logger().Printf("rejected update of zone %s from %s, key %s may not update it", zone, w.RemoteAddr(), keyName)
+		return dns.RcodeRefused
+	}
+
+	timeout := g.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	rcode, err := g.update(ctx, zone, r.Answer, r.Ns)
+	if err != nil {
+		g.logger().Printf("update of zone %s failed: %s", zone, err)
+	}
+
+	return rcode
+}
+
+// update checks the prerequisites and applies the updates. It returns the RCODE of the response.
+func (g *Gateway) update(ctx context.Context, zone string, prerequisites []dns.RR, updates []dns.RR) (int, error) {
+	for _, rr := range append(append([]dns.RR{}, prerequisites...), updates...) {
+		if !inZone(rr.Header().Name, zone) {
+			return dns.RcodeNotZone, fmt.Errorf("'%s' is not in zone %s", rr.Header().Name, zone)
+		}
+	}
+
+	records, err := g.Provider.GetRecords(ctx, zone)
+	if err != nil {
+		return rcodeFor(err), err
+	}
+
+	rcode := checkPrerequisites(zone, records, prerequisites)
+	if rcode != dns.RcodeSuccess {
+		return rcode, nil
+	}
+
+	//Validate all updates before anything is changed
+	var toDelete, toAppend []libdns.Record
+	for _, rr := range updates {
+		header := rr.Header()
+		name := relativeName(header.Name, zone)
+
+		switch header.Class {
+		case dns.ClassINET:
+			record, err := toRecord(zone, rr)
+			if err != nil {
+				return dns.RcodeNotImplemented, err
+			}
+			toAppend = append(toAppend, record)
+		case dns.ClassANY:
+			if header.Rdlength != 0 {
+				return dns.RcodeFormatError, fmt.Errorf("deletion of RRset '%s' has data", header.Name)
+			}
+			for _, record := range records {
+				//The NS records of the apex are kept, see RFC 2136, section 3.4.2.3
+				if isApex(record.Name) && record.Type == "NS" {
+					continue
+				}
+				if sameName(record.Name, name) && (header.Rrtype == dns.TypeANY || strings.EqualFold(record.Type, dns.TypeToString[header.Rrtype])) {
+					toDelete = append(toDelete, record)
+				}
+			}
+		case dns.ClassNONE:
+			deleted, err := toRecord(zone, rr)
+			if err != nil {
+				return dns.RcodeNotImplemented, err
+			}
+			for _, record := range records {
+				if !sameRecord(record, deleted) {
+					continue
+				}
+				//Deleting the last NS record of the apex is silently ignored, see RFC 2136, section 3.4.2.4
+				if isApex(record.Name) && record.Type == "NS" && remainingApexNS(records, toDelete) <= 1 {
+					continue
+				}
+				toDelete = append(toDelete, record)
+			}
+		default:
+			return dns.RcodeFormatError, fmt.Errorf("update of '%s' has unsupported class %d", header.Name, header.Class)
+		}
+	}
+
+	toDelete = uniqueRecords(toDelete)
+	toAppend = newRecords(records, toDelete, toAppend)
+	if len(toDelete) > 0 {
+		_, err = g.Provider.DeleteRecords(ctx, zone, toDelete)
+		if err != nil {
+			return rcodeFor(err), err
+		}
+	}
+
+	if len(toAppend) > 0 {
+		_, err = g.Provider.AppendRecords(ctx, zone, toAppend)
+		if err != nil {
+			return rcodeFor(err), err
+		}
+	}
+
+	return dns.RcodeSuccess, nil
+}
+
+// checkPrerequisites checks the prerequisite section as described in RFC 2136, section 2.4.
+func checkPrerequisites(zone string, records []libdns.Record, prerequisites []dns.RR) int {
+	//Value dependent prerequisites of a name and type form an RRset that is compared as a whole, see RFC 2136,
+	//section 3.2.5
+	var expectedRRsets [][]libdns.Record
+
+	for _, rr := range prerequisites {
+		header := rr.Header()
+		name := relativeName(header.Name, zone)
+		recordType := dns.TypeToString[header.Rrtype]
+
+		nameInUse, rrsetExists := false, false
+		for _, record := range records {
+			if sameName(record.Name, name) {
+				nameInUse = true
+				if strings.EqualFold(record.Type, recordType) {
+					rrsetExists = true
+				}
+			}
+		}
+
+		switch {
+		case header.Class == dns.ClassANY && header.Rrtype == dns.TypeANY:
+			if !nameInUse {
+				return dns.RcodeNameError
+			}
+		case header.Class == dns.ClassANY:
+			if !rrsetExists {
+				return dns.RcodeNXRrset
+			}
+		case header.Class == dns.ClassNONE && header.Rrtype == dns.TypeANY:
+			if nameInUse {
+				return dns.RcodeYXDomain
+			}
+		case header.Class == dns.ClassNONE:
+			if rrsetExists {
+				return dns.RcodeYXRrset
+			}
+		case header.Class == dns.ClassINET:
+			expected, err := toRecord(zone, rr)
+			if err != nil {
+				return dns.RcodeNotImplemented
+			}
+			index := slices.IndexFunc(expectedRRsets, func(rrset []libdns.Record) bool { return sameRRset(rrset[0], expected) })
+			if index < 0 {
+				expectedRRsets = append(expectedRRsets, []libdns.Record{expected})
+			} else {
+				expectedRRsets[index] = append(expectedRRsets[index], expected)
+			}
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	for _, expected := range expectedRRsets {
+		var current []libdns.Record
+		for _, record := range records {
+			if sameRRset(record, expected[0]) {
+				current = append(current, record)
+			}
+		}
+
+		if !containsRecords(current, expected) || !containsRecords(expected, current) {
+			return dns.RcodeNXRrset
+		}
+	}
+
+	return dns.RcodeSuccess
+}
+
+// containsRecords reports whether every record of b is also in a.
+func containsRecords(a []libdns.Record, b []libdns.Record) bool {
+	for _, record := range b {
+		if !slices.ContainsFunc(a, func(candidate libdns.Record) bool { return sameRecord(candidate, record) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// toRecord converts a resource record into a record with a name relative to the zone.
+func toRecord(zone string, rr dns.RR) (libdns.Record, error) {
+	header := rr.Header()
+	record := libdns.Record{
+		Type: dns.TypeToString[header.Rrtype],
+		Name: relativeName(header.Name, zone),
+		TTL:  time.Duration(header.Ttl) * time.Second,
+	}
+
+	switch value := rr.(type) {
+	case *dns.A:
+		record.Value = value.A.String()
+	case *dns.AAAA:
+		record.Value = value.AAAA.String()
+	case *dns.CNAME:
+		record.Value = strings.TrimSuffix(value.Target, ".")
+	case *dns.MX:
+		record.Value = strings.TrimSuffix(value.Mx, ".")
+		record.Priority = uint(value.Preference)
+	case *dns.NS:
+		record.Value = strings.TrimSuffix(value.Ns, ".")
+	case *dns.TXT:
+		record.Value = strings.Join(value.Txt, "")
+	case *dns.TLSA:
+		record.Value = fmt.Sprintf("%d %d %d %s", value.Usage, value.Selector, value.MatchingType, value.Certificate)
+	default:
+		return libdns.Record{}, fmt.Errorf("record type %s is not supported", record.Type)
+	}
+
+	return record, nil
+}
+
+func sameRecord(a libdns.Record, b libdns.Record) bool {
+	return sameRRset(a, b) && strings.EqualFold(a.Value, b.Value) && a.Priority == b.Priority
+}
+
+// sameRRset reports whether the records have the same name and type.
+func sameRRset(a libdns.Record, b libdns.Record) bool {
+	return sameName(a.Name, b.Name) && strings.EqualFold(a.Type, b.Type)
+}
+
+// sameName compares names relative to the zone, ignoring their case.
+func sameName(a string, b string) bool {
+	if isApex(a) || isApex(b) {
+		return isApex(a) && isApex(b)
+	}
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// uniqueRecords removes records that are deleted by more than one update.
+func uniqueRecords(records []libdns.Record) []libdns.Record {
+	seen := map[string]bool{}
+	var unique []libdns.Record
+	for _, record := range records {
+		if !seen[record.ID] {
+			seen[record.ID] = true
+			unique = append(unique, record)
+		}
+	}
+	return unique
+}
+
+// newRecords removes the records to append that already exist and are not deleted, or that are added more than once.
+// Adding an existing record is silently ignored, see RFC 2136, section 3.4.2.2.
+func newRecords(records []libdns.Record, toDelete []libdns.Record, toAppend []libdns.Record) []libdns.Record {
+	deleted := map[string]bool{}
+	for _, record := range toDelete {
+		deleted[record.ID] = true
+	}
+
+	var existing []libdns.Record
+	for _, record := range records {
+		if !deleted[record.ID] {
+			existing = append(existing, record)
+		}
+	}
+
+	var added []libdns.Record
+	for _, record := range toAppend {
+		if !slices.ContainsFunc(existing, func(existingRecord libdns.Record) bool { return sameRecord(existingRecord, record) }) {
+			added = append(added, record)
+			existing = append(existing, record)
+		}
+	}
+	return added
+}
+
+// remainingApexNS counts the NS records of the apex that are not deleted yet.
+func remainingApexNS(records []libdns.Record, toDelete []libdns.Record) int {
+	count := 0
+	for _, record := range records {
+		if isApex(record.Name) && record.Type == "NS" && !slices.ContainsFunc(toDelete, func(deleted libdns.Record) bool { return deleted.ID == record.ID }) {
+			count++
+		}
+	}
+	return count
+}
+
+func isApex(name string) bool {
+	return name == "" || name == "@"
+}
+
+// rcodeFor maps errors of the provider to RCODEs. Changes the API rejects are refused, everything else is a
+// server failure.
+func rcodeFor(err error) int {
+	var validationError hosttech.ValidationError
+	if errors.As(err, &validationError) {
+		return dns.RcodeRefused
+	}
+
+	var ownershipError hosttech.OwnershipError
+	if errors.As(err, &ownershipError) {
+		return dns.RcodeRefused
+	}
+
+	var apiError hosttech.ApiError
+	if errors.As(err, &apiError) && apiError.ErrorCode >= http.StatusBadRequest && apiError.ErrorCode < http.StatusInternalServerError {
+		return dns.RcodeRefused
+	}
+
+	return dns.RcodeServerFailure
+}
+
+// zone returns the configured zone matching the name, or an empty string.
+func (g *Gateway) zone(name string) string {
+	for _, zone := range g.Zones {
+		if strings.EqualFold(dns.Fqdn(zone), dns.Fqdn(name)) {
+			return strings.TrimSuffix(zone, ".")
+		}
+	}
+	return ""
+}
+
+// keyMayUpdate reports whether the TSIG key may update the zone.
+func (g *Gateway) keyMayUpdate(keyName string, zone string) bool {
+	if g.KeyZones == nil {
+		return true
+	}
+
+	for name, zones := range g.KeyZones {
+		if !strings.EqualFold(dns.Fqdn(name), dns.Fqdn(keyName)) {
+			continue
+		}
+		return slices.ContainsFunc(zones, func(keyZone string) bool { return strings.EqualFold(dns.Fqdn(keyZone), dns.Fqdn(zone)) })
+	}
+	return false
+}
+
+func (g *Gateway) logger() *log.Logger {
+	if g.Logger != nil {
+		return g.Logger
+	}
+	return log.Default()
+}
+
+func inZone(name string, zone string) bool {
+	return dns.IsSubDomain(dns.Fqdn(zone), dns.Fqdn(name))
+}
+
+func relativeName(name string, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return libdns.RelativeName(name, strings.ToLower(zone))
+}
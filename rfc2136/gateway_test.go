@@ -0,0 +1,277 @@
+package rfc2136
+
+import (
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech"
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	keyName = "update-key."
+	secret  = "c2VjcmV0LXNlY3JldC1zZWNyZXQ="
+)
+
+func TestGateway(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "www", "text": "hello", "ttl": 3600})
+
+	address := startGateway(t, &Gateway{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Zones:    []string{"example.com"},
+		Logger:   log.New(io.Discard, "", 0),
+	})
+
+	exchange := func(m *dns.Msg, sign bool) (*dns.Msg, error) {
+		client := &dns.Client{Timeout: 5 * time.Second}
+		if sign {
+			m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+			client.TsigSecret = map[string]string{keyName: secret}
+		}
+		response, _, err := client.Exchange(m, address)
+		return response, err
+	}
+
+	//Rejected updates do not change the zone
+	input := map[string]struct {
+		message       func() *dns.Msg
+		sign          bool
+		expectedRcode int
+	}{
+		"Unsigned Update Test": {
+			message:       func() *dns.Msg { return updateMsg("example.com.", "www.example.com. 600 IN A 1.2.3.5") },
+			expectedRcode: dns.RcodeRefused,
+		},
+		"Unknown Zone Test": {
+			message:       func() *dns.Msg { return updateMsg("example.org.", "www.example.org. 600 IN A 1.2.3.5") },
+			sign:          true,
+			expectedRcode: dns.RcodeNotAuth,
+		},
+		"Name Outside Of Zone Test": {
+			message:       func() *dns.Msg { return updateMsg("example.com.", "www.example.org. 600 IN A 1.2.3.5") },
+			sign:          true,
+			expectedRcode: dns.RcodeNotZone,
+		},
+		"TTL Below Minimum Test": {
+			message:       func() *dns.Msg { return updateMsg("example.com.", "api.example.com. 60 IN A 1.2.3.5") },
+			sign:          true,
+			expectedRcode: dns.RcodeRefused,
+		},
+		"Unsupported Type Test": {
+			message:       func() *dns.Msg { return updateMsg("example.com.", "api.example.com. 600 IN HINFO cpu os") },
+			sign:          true,
+			expectedRcode: dns.RcodeNotImplemented,
+		},
+		"Prerequisite Not Met Test": {
+			message: func() *dns.Msg {
+				m := updateMsg("example.com.", "api.example.com. 600 IN A 1.2.3.5")
+				m.NameUsed([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: "api.example.com."}}})
+				return m
+			},
+			sign:          true,
+			expectedRcode: dns.RcodeNameError,
+		},
+	}
+
+	for desc, tc := range input {
+		t.Run(desc, func(t *testing.T) {
+			response, err := exchange(tc.message(), tc.sign)
+			if tc.expectedRcode == dns.RcodeNotAuth {
+				//miekg/dns does not verify signed NOTAUTH responses and fails instead
+				assert.ErrorIs(t, err, dns.ErrAuth)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, dns.RcodeToString[tc.expectedRcode], dns.RcodeToString[response.Rcode])
+		})
+	}
+	assert.Len(t, api.Records("example.com"), 2)
+
+	//Replace the RRset
+	m := updateMsg("example.com.", "www.example.com. 600 IN A 1.2.3.5")
+	m.RRsetUsed([]dns.RR{mustRR("www.example.com. 0 IN A 0.0.0.0")})
+	m.RemoveRRset([]dns.RR{mustRR("www.example.com. 0 IN A 0.0.0.0")})
+	response, err := exchange(m, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeToString[dns.RcodeSuccess], dns.RcodeToString[response.Rcode])
+
+	//Delete a single record
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Remove([]dns.RR{mustRR(`www.example.com. 0 IN TXT "hello"`)})
+	response, err = exchange(m, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dns.RcodeToString[dns.RcodeSuccess], dns.RcodeToString[response.Rcode])
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 1)
+	assert.Equal(t, "A", records[0]["type"])
+	assert.Equal(t, "www", records[0]["name"])
+	assert.Equal(t, "1.2.3.5", records[0]["ipv4"])
+}
+
+func TestGateway_BadSignature(t *testing.T) {
+	address := startGateway(t, &Gateway{Zones: []string{"example.com"}, Logger: log.New(io.Discard, "", 0)})
+
+	m := updateMsg("example.com.", "www.example.com. 600 IN A 1.2.3.5")
+	m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+	client := &dns.Client{TsigSecret: map[string]string{keyName: "d3Jvbmctc2VjcmV0"}}
+
+	response, _, _ := client.Exchange(m, address)
+	assert.NotNil(t, response)
+	assert.Equal(t, dns.RcodeNotAuth, response.Rcode)
+}
+
+func TestGateway_KeyZones(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com", "example.org")
+	defer api.Close()
+
+	address := startGateway(t, &Gateway{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Zones:    []string{"example.com", "example.org"},
+		KeyZones: map[string][]string{"Update-Key": {"example.org."}},
+		Logger:   log.New(io.Discard, "", 0),
+	})
+
+	exchange := func(m *dns.Msg) *dns.Msg {
+		m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+		client := &dns.Client{Timeout: 5 * time.Second, TsigSecret: map[string]string{keyName: secret}}
+		response, _, err := client.Exchange(m, address)
+		assert.NoError(t, err)
+		return response
+	}
+
+	response := exchange(updateMsg("example.com.", "www.example.com. 600 IN A 1.2.3.5"))
+	assert.Equal(t, dns.RcodeToString[dns.RcodeRefused], dns.RcodeToString[response.Rcode])
+	assert.Empty(t, api.Records("example.com"))
+
+	response = exchange(updateMsg("example.org.", "www.example.org. 600 IN A 1.2.3.5"))
+	assert.Equal(t, dns.RcodeToString[dns.RcodeSuccess], dns.RcodeToString[response.Rcode])
+	assert.Len(t, api.Records("example.org"), 1)
+}
+
+func TestGateway_Apex(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "NS", "ownername": "", "targetname": "ns1.hosttech.eu", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "NS", "ownername": "", "targetname": "ns2.hosttech.eu", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "", "text": "v=spf1 -all", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+
+	address := startGateway(t, &Gateway{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Zones:    []string{"example.com"},
+		Logger:   log.New(io.Discard, "", 0),
+	})
+
+	exchange := func(m *dns.Msg) {
+		m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+		client := &dns.Client{Timeout: 5 * time.Second, TsigSecret: map[string]string{keyName: secret}}
+		response, _, err := client.Exchange(m, address)
+		assert.NoError(t, err)
+		assert.Equal(t, dns.RcodeToString[dns.RcodeSuccess], dns.RcodeToString[response.Rcode])
+	}
+
+	//Deleting all RRsets of the apex keeps its NS records
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.RemoveName([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: "example.com."}}})
+	exchange(m)
+
+	//The last NS record of the apex is not deleted
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Remove([]dns.RR{mustRR("example.com. 0 IN NS ns1.hosttech.eu."), mustRR("example.com. 0 IN NS ns2.hosttech.eu.")})
+	exchange(m)
+
+	//Adding an existing record is ignored
+	exchange(updateMsg("example.com.", "www.example.com. 3600 IN A 1.2.3.4"))
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 2)
+	assert.Equal(t, "ns2.hosttech.eu", records[0]["targetname"])
+	assert.Equal(t, "www", records[1]["name"])
+}
+
+func TestGateway_Prerequisites(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.5", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "TXT", "name": "Mail", "text": "hello", "ttl": 3600})
+
+	address := startGateway(t, &Gateway{
+		Provider: &hosttech.Provider{APIToken: "token", APIURL: api.URL},
+		Zones:    []string{"example.com"},
+		Logger:   log.New(io.Discard, "", 0),
+	})
+
+	exchange := func(m *dns.Msg, expectedRcode int) {
+		m.SetTsig(keyName, dns.HmacSHA256, 300, time.Now().Unix())
+		client := &dns.Client{Timeout: 5 * time.Second, TsigSecret: map[string]string{keyName: secret}}
+		response, _, err := client.Exchange(m, address)
+		assert.NoError(t, err)
+		assert.Equal(t, dns.RcodeToString[expectedRcode], dns.RcodeToString[response.Rcode])
+	}
+
+	//A value dependent prerequisite has to name the whole RRset
+	m := updateMsg("example.com.", "api.example.com. 600 IN A 1.2.3.6")
+	m.Used([]dns.RR{mustRR("www.example.com. 0 IN A 1.2.3.4")})
+	exchange(m, dns.RcodeNXRrset)
+
+	m = updateMsg("example.com.", "api.example.com. 600 IN A 1.2.3.6")
+	m.Used([]dns.RR{mustRR("WWW.example.com. 0 IN A 1.2.3.5"), mustRR("www.example.com. 0 IN A 1.2.3.4")})
+	exchange(m, dns.RcodeSuccess)
+
+	//Names are compared case-insensitively
+	m = new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.NameUsed([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: "mail.example.com."}}})
+	m.RemoveRRset([]dns.RR{mustRR("MAIL.example.com. 0 IN TXT \"\"")})
+	exchange(m, dns.RcodeSuccess)
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 3)
+	assert.Equal(t, "api", records[2]["name"])
+}
+
+func startGateway(t *testing.T, gateway *Gateway) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &dns.Server{
+		PacketConn:    conn,
+		Handler:       gateway,
+		TsigSecret:    map[string]string{keyName: secret},
+		MsgAcceptFunc: AcceptUpdates,
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String()
+}
+
+func updateMsg(zone string, insert string) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	m.Insert([]dns.RR{mustRR(insert)})
+	return m
+}
+
+func mustRR(s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		panic(err)
+	}
+	return rr
+}
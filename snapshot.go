@@ -0,0 +1,286 @@
+package hosttech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libdns/hosttech/internal/atomicfile"
+	"github.com/libdns/libdns"
+)
+
+// The version of the snapshot file format written by this library
+const snapshotVersion = 1
+
+// Snapshot is the full record set of a zone at a point in time.
+type Snapshot struct {
+	Version   int              `json:"version"`
+	Zone      string           `json:"zone"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Records   []SnapshotRecord `json:"records"`
+}
+
+// SnapshotRecord is a record as it is stored in a snapshot, including its ID and comment at Hosttech.
+type SnapshotRecord struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	TTL      int    `json:"ttl"`
+	Priority uint   `json:"priority,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Record converts the snapshot record to a libdns record.
+func (s SnapshotRecord) Record() libdns.Record {
+	return libdns.Record{
+		ID:       s.ID,
+		Type:     s.Type,
+		Name:     s.Name,
+		Value:    s.Value,
+		TTL:      time.Duration(s.TTL) * time.Second,
+		Priority: s.Priority,
+	}
+}
+
+func newSnapshotRecord(record libdns.Record, comment string) SnapshotRecord {
+	return SnapshotRecord{
+		ID:       record.ID,
+		Type:     record.Type,
+		Name:     record.Name,
+		Value:    record.Value,
+		TTL:      durationToIntSeconds(record.TTL),
+		Priority: record.Priority,
+		Comment:  comment,
+	}
+}
+
+// Snapshot captures all records of the zone together with their IDs and comments.
+func (p *Provider) Snapshot(ctx context.Context, zone string) (_ Snapshot, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Snapshot", zone, nil)
+	defer endSpan(&err)
+
+	records, err := p.GetRecordsWithComments(ctx, zone)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		Version:   snapshotVersion,
+		Zone:      zone,
+		CreatedAt: time.Now().UTC(),
+		Records:   []SnapshotRecord{},
+	}
+	for _, record := range records {
+		snapshot.Records = append(snapshot.Records, newSnapshotRecord(record.Record, record.Comment))
+	}
+
+	return snapshot, nil
+}
+
+// WriteFile writes the snapshot as JSON to the file, replacing it atomically if it exists.
+func (s Snapshot) WriteFile(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(path, content, 0o600)
+}
+
+// ReadSnapshot reads a snapshot written by WriteFile. Snapshots of a newer format than this library supports are
+// rejected instead of being restored partially.
+func ReadSnapshot(path string) (Snapshot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{}
+	err = json.Unmarshal(content, &snapshot)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("could not parse snapshot '%s': %w", path, err)
+	}
+
+	if snapshot.Version < 1 || snapshot.Version > snapshotVersion {
+		return Snapshot{}, fmt.Errorf("snapshot '%s' has unsupported version %d", path, snapshot.Version)
+	}
+	if snapshot.Zone == "" {
+		return Snapshot{}, fmt.Errorf("snapshot '%s' has no zone", path)
+	}
+
+	return snapshot, nil
+}
+
+// PlanRestore computes the changes needed to bring the zone back to the state of the snapshot, without applying them.
+// Records are matched the same way as in Plan, so the IDs in the snapshot do not need to exist anymore. Unlike Plan,
+// records whose comment differs from the snapshot are updated, unless ownership is configured or comments are
+// disabled, because Restore would not write the comment of the snapshot then.
+func (p *Provider) PlanRestore(ctx context.Context, snapshot Snapshot) (_ Plan, err error) {
+	ctx, endSpan := p.startSpan(ctx, "PlanRestore", snapshot.Zone, nil)
+	defer endSpan(&err)
+
+	var desired []libdns.Record
+	for _, record := range snapshot.Records {
+		desired = append(desired, record.Record())
+	}
+
+	plan, current, err := p.plan(ctx, snapshot.Zone, desired)
+	if err != nil || p.Ownership != nil || p.CommentMode == CommentDisabled {
+		return plan, err
+	}
+
+	changed := map[string]bool{}
+	for _, change := range plan.Changes {
+		changed[change.Current.ID] = true
+	}
+
+	records := snapshotRecords(snapshot)
+	for _, record := range current {
+		currentRecord := record.toLibdnsRecord(snapshot.Zone)
+		snapshotRecord, ok := records[snapshotKey(snapshot.Zone, currentRecord)]
+		//Empty comments are left out of requests, so they can not be restored
+		if !ok || changed[currentRecord.ID] || snapshotRecord.Comment == "" || snapshotRecord.Comment == record.value.comment() {
+			continue
+		}
+
+		plan.Changes = append(plan.Changes, Change{Action: ChangeUpdate, Current: currentRecord, Desired: snapshotRecord.Record()})
+	}
+	plan.Changes = orderChanges(snapshot.Zone, plan.Changes)
+
+	return plan, nil
+}
+
+// Restore brings the zone back to the state of the snapshot and returns the applied changes. Deleted records
+// are recreated, changed records are updated and records created since the snapshot are deleted. Recreated and
+// updated records get their comment from the snapshot, unless ownership is configured or comments are disabled.
+// In dry-run mode the requests are recorded instead of being sent.
+// If an error occurs, the already applied changes will be returned along with an error.
+func (p *Provider) Restore(ctx context.Context, snapshot Snapshot) (_ []Change, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Restore", snapshot.Zone, nil)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, snapshot.Zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	ctx = p.shareZoneReads(ctx, snapshot.Zone)
+
+	plan, err := p.PlanRestore(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	records := snapshotRecords(snapshot)
+
+	appliedChanges := []Change{}
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case ChangeDelete:
+			_, err = p.DeleteRecords(ctx, plan.Zone, []libdns.Record{change.Current})
+		case ChangeUpdate:
+			record := change.Desired
+			record.ID = change.Current.ID
+			err = p.restoreRecord(ctx, plan.Zone, record, records[snapshotKey(plan.Zone, change.Desired)].Comment)
+		case ChangeCreate:
+			record := change.Desired
+			record.ID = ""
+			err = p.restoreRecord(ctx, plan.Zone, record, records[snapshotKey(plan.Zone, change.Desired)].Comment)
+		}
+
+		if err != nil {
+			return appliedChanges, err
+		}
+
+		appliedChanges = append(appliedChanges, change)
+	}
+
+	return appliedChanges, nil
+}
+
+// restoreRecord creates the record, or updates it if it has an ID, with the comment from the snapshot.
+func (p *Provider) restoreRecord(ctx context.Context, zone string, record libdns.Record, comment string) error {
+	operation := JournalAppend
+	var ids []string
+	if record.ID != "" {
+		operation = JournalSet
+		ids = []string{record.ID}
+	}
+
+	before, err := p.journalState(ctx, zone, ids)
+	if err != nil {
+		return err
+	}
+
+	writtenRecords, err := p.writeRecord(ctx, zone, record, comment)
+	return p.journalChange(zone, operation, before, writtenRecords, err)
+}
+
+// writeRecord creates the record, or updates it if it has an ID, with the comment. Unlike AppendRecords and
+// SetRecords, the comment is not determined by the comment mode, so comments from a snapshot or journal survive.
+func (p *Provider) writeRecord(ctx context.Context, zone string, record libdns.Record, comment string) ([]libdns.Record, error) {
+	if p.Ownership != nil {
+		comment = p.Ownership.String()
+	} else if p.CommentMode == CommentDisabled {
+		comment = ""
+	}
+
+	hosttechRecord, err := p.validatedRecord(zone, record, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyBytes, err := json.Marshal(hosttechRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	method := http.MethodPost
+	reqURL := fmt.Sprintf("%s/zones/%s/records", p.baseURL(), zone)
+	if record.ID != "" {
+		err = p.checkOwnership(ctx, zone, []string{record.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		err = p.checkConflicts(ctx, zone, []string{record.ID})
+		if err != nil {
+			return nil, err
+		}
+
+		method = http.MethodPut
+		reqURL += "/" + record.ID
+	}
+
+	responseBody, err := p.makeApiCall(ctx, method, reqURL, bytes.NewReader(bodyBytes), zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedResponse = HosttechSingleResponseWrapper{}
+	err = json.Unmarshal(responseBody, &parsedResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return []libdns.Record{parsedResponse.Data.toLibdnsRecord(zone)}, nil
+}
+
+// snapshotRecords indexes the records of the snapshot by name, type and value.
+func snapshotRecords(snapshot Snapshot) map[string]SnapshotRecord {
+	records := map[string]SnapshotRecord{}
+	for _, record := range snapshot.Records {
+		records[snapshotKey(snapshot.Zone, record.Record())] = record
+	}
+	return records
+}
+
+func snapshotKey(zone string, record libdns.Record) string {
+	return NormalizeName(record.Name, zone) + "\x00" + strings.ToUpper(record.Type) + "\x00" + record.Value
+}
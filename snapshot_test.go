@@ -0,0 +1,83 @@
+package hosttech
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_SnapshotRestore(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600, "comment": "web server"})
+	api.AddRecord("example.com", map[string]any{"type": "MX", "ownername": "", "name": "mail.example.com", "pref": 10, "ttl": 3600, "comment": "mail"})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	snapshot, err := provider.Snapshot(ctx, "example.com")
+	assert.NoError(t, err)
+	assert.Len(t, snapshot.Records, 3)
+	assert.Equal(t, "web server", snapshot.Records[0].Comment)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.NoError(t, snapshot.WriteFile(path))
+	snapshot, err = ReadSnapshot(path)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), snapshot.Records[1].Priority)
+
+	//Delete the web server, change the API, overwrite the comment of the mail server and add a record that did not
+	//exist in the snapshot
+	records, err := provider.GetRecords(ctx, "example.com")
+	assert.NoError(t, err)
+	_, err = provider.DeleteRecords(ctx, "example.com", records[:1])
+	assert.NoError(t, err)
+	records[2].Value = "9.9.9.9"
+	_, err = provider.SetRecords(ctx, "example.com", records[1:])
+	assert.NoError(t, err)
+	_, err = provider.AppendRecords(ctx, "example.com", []libdns.Record{{Type: "TXT", Name: "new", Value: "created later"}})
+	assert.NoError(t, err)
+
+	plan, err := provider.PlanRestore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.Len(t, plan.Changes, 4)
+
+	dryRun := Provider{APIToken: "token", APIURL: api.URL, DryRun: true, DryRunLogger: log.New(io.Discard, "", 0)}
+	_, err = dryRun.Restore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.Len(t, dryRun.DryRunOperations(), 4)
+	assert.Len(t, api.Records("example.com"), 3)
+
+	applied, err := provider.Restore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 4)
+
+	restored, err := provider.Snapshot(ctx, "example.com")
+	assert.NoError(t, err)
+	plan, err = provider.PlanRestore(ctx, snapshot)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+	for _, record := range restored.Records {
+		switch record.Name {
+		case "www":
+			assert.Equal(t, "web server", record.Comment)
+		case "":
+			assert.Equal(t, "mail", record.Comment)
+		}
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.NoError(t, Snapshot{Version: snapshotVersion + 1, Zone: "example.com"}.WriteFile(path))
+
+	_, err := ReadSnapshot(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,301 @@
+package hosttech
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ChangeAction describes what a planned change does to a record in the zone.
+type ChangeAction string
+
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+	ChangeDelete ChangeAction = "delete"
+)
+
+// Change is a single modification needed to bring a zone to its desired state.
+// Current is empty for creations, Desired is empty for deletions.
+type Change struct {
+	Action  ChangeAction
+	Current libdns.Record
+	Desired libdns.Record
+}
+
+// Plan holds all changes needed to bring a zone to its desired state, in the order they will be applied.
+type Plan struct {
+	Zone    string
+	Changes []Change
+}
+
+// Empty reports whether the zone already matches the desired state.
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan in a reviewable, diff-like format with one change per line.
+func (p Plan) String() string {
+	if p.Empty() {
+		return fmt.Sprintf("zone %s is up to date\n", p.Zone)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "zone %s: %d change(s)\n", p.Zone, len(p.Changes))
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ChangeCreate:
+			fmt.Fprintf(&sb, "+ %s\n", formatRecord(change.Desired))
+		case ChangeUpdate:
+			fmt.Fprintf(&sb, "~ %s -> %s\n", formatRecord(change.Current), formatRecord(change.Desired))
+		case ChangeDelete:
+			fmt.Fprintf(&sb, "- %s\n", formatRecord(change.Current))
+		}
+	}
+
+	return sb.String()
+}
+
+// Plan computes the changes needed to turn the records currently in the zone into the desired records.
+// Records are matched by name, type and value. If only one record of a name and type is left unmatched, it is updated
+// in place. Comments are not part of the comparison, so records only differing in the comment Hosttech or this
+// library stamped on them are left untouched. A desired TTL of zero matches any TTL.
+// If ownership is enforced, records owned by someone else are not part of the plan.
+func (p *Provider) Plan(ctx context.Context, zone string, desired []libdns.Record) (_ Plan, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Plan", zone, desired)
+	defer endSpan(&err)
+
+	plan, _, err := p.plan(ctx, zone, desired)
+	return plan, err
+}
+
+// plan computes the plan like Plan and also returns the current records it was computed from.
+func (p *Provider) plan(ctx context.Context, zone string, desired []libdns.Record) (Plan, []HosttechRecordWrapper, error) {
+	//Fail early on records that could never be applied
+	err := p.ValidateRecords(zone, desired)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+
+	hosttechRecords, err := p.getHosttechRecords(ctx, zone)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+	p.rememberRecords(zone, hosttechRecords, true)
+
+	//Records owned by someone else are left alone if ownership is enforced
+	var currentRecords []HosttechRecordWrapper
+	var current []libdns.Record
+	for _, record := range hosttechRecords {
+		if p.Ownership != nil && p.Ownership.Enforce && !p.Ownership.OwnsComment(record.value.comment()) {
+			continue
+		}
+		currentRecords = append(currentRecords, record)
+		current = append(current, record.toLibdnsRecord(zone))
+	}
+
+	return Plan{
+		Zone:    zone,
+		Changes: DiffRecords(zone, current, desired),
+	}, currentRecords, nil
+}
+
+// Apply executes the changes of a plan. Updates run first, then creations and finally deletions, so that a failed
+// change never leaves the zone without records it had before. Creations that would conflict with a record that is
+// deleted, a CNAME next to another record of the same name, run after the deletions.
+// If an error occurs, the already applied changes will be returned along with an error.
+func (p *Provider) Apply(ctx context.Context, plan Plan) (_ []Change, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Apply", plan.Zone, nil)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, plan.Zone)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	ctx = p.shareZoneReads(ctx, plan.Zone)
+
+	appliedChanges := []Change{}
+	for _, change := range orderChanges(plan.Zone, plan.Changes) {
+		var err error
+		switch change.Action {
+		case ChangeDelete:
+			_, err = p.DeleteRecords(ctx, plan.Zone, []libdns.Record{change.Current})
+		case ChangeUpdate:
+			record := change.Desired
+			record.ID = change.Current.ID
+			if record.TTL == 0 {
+				record.TTL = change.Current.TTL
+			}
+			_, err = p.SetRecords(ctx, plan.Zone, []libdns.Record{record})
+		case ChangeCreate:
+			_, err = p.AppendRecords(ctx, plan.Zone, []libdns.Record{change.Desired})
+		default:
+			err = fmt.Errorf(`change action "%s" is not supported`, change.Action)
+		}
+
+		if err != nil {
+			return appliedChanges, err
+		}
+
+		appliedChanges = append(appliedChanges, change)
+	}
+
+	return appliedChanges, nil
+}
+
+// Sync brings the zone to the desired state by computing a plan and applying it right away.
+// It returns the plan that was applied.
+func (p *Provider) Sync(ctx context.Context, zone string, desired []libdns.Record) (_ Plan, err error) {
+	ctx, endSpan := p.startSpan(ctx, "Sync", zone, desired)
+	defer endSpan(&err)
+
+	ctx, unlock, err := p.lockZone(ctx, zone)
+	if err != nil {
+		return Plan{}, err
+	}
+	defer unlock()
+
+	plan, err := p.Plan(ctx, zone, desired)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	_, err = p.Apply(ctx, plan)
+	return plan, err
+}
+
+// DiffRecords computes the changes needed to turn the current records into the desired records, matching them the
+// same way as Plan. It does not call the API, so it can be used to diff a subset of a zone.
+func DiffRecords(zone string, current []libdns.Record, desired []libdns.Record) []Change {
+	var changes []Change
+
+	//First pass: pair records with the same name, type and value
+	unmatchedCurrent := append([]libdns.Record{}, current...)
+	var unmatchedDesired []libdns.Record
+	for _, want := range desired {
+		index := indexOfRecord(zone, unmatchedCurrent, want, true)
+		if index < 0 {
+			unmatchedDesired = append(unmatchedDesired, want)
+			continue
+		}
+
+		have := unmatchedCurrent[index]
+		unmatchedCurrent = append(unmatchedCurrent[:index], unmatchedCurrent[index+1:]...)
+		if (want.TTL != 0 && want.TTL != have.TTL) || want.Priority != have.Priority {
+			changes = append(changes, Change{Action: ChangeUpdate, Current: have, Desired: want})
+		}
+	}
+
+	//Second pass: a record that only shares name and type is updated in place, e.g. an A record with a new address.
+	//If several records of the name and type are left, it is unclear which one was meant, so they are replaced.
+	for _, want := range unmatchedDesired {
+		index := indexOfRecord(zone, unmatchedCurrent, want, false)
+		if index < 0 || indexOfRecord(zone, unmatchedCurrent[index+1:], want, false) >= 0 {
+			changes = append(changes, Change{Action: ChangeCreate, Desired: want})
+			continue
+		}
+
+		have := unmatchedCurrent[index]
+		unmatchedCurrent = append(unmatchedCurrent[:index], unmatchedCurrent[index+1:]...)
+		changes = append(changes, Change{Action: ChangeUpdate, Current: have, Desired: want})
+	}
+
+	for _, have := range unmatchedCurrent {
+		changes = append(changes, Change{Action: ChangeDelete, Current: have})
+	}
+
+	return orderChanges(zone, changes)
+}
+
+func indexOfRecord(zone string, records []libdns.Record, record libdns.Record, matchValue bool) int {
+	for i, candidate := range records {
+		if !strings.EqualFold(candidate.Type, record.Type) {
+			continue
+		}
+		if NormalizeName(candidate.Name, zone) != NormalizeName(record.Name, zone) {
+			continue
+		}
+		if matchValue && candidate.Value != record.Value {
+			continue
+		}
+		return i
+	}
+
+	return -1
+}
+
+// orderChanges sorts changes into updates, creations and deletions, each sorted by name and type. Creations of a
+// CNAME for a name that loses records, or of any record for a name that loses its CNAME, come last.
+func orderChanges(zone string, changes []Change) []Change {
+	deletedNames := map[string]bool{}
+	deletedCNAMEs := map[string]bool{}
+	for _, change := range changes {
+		if change.Action == ChangeDelete {
+			name := NormalizeName(change.Current.Name, zone)
+			deletedNames[name] = true
+			deletedCNAMEs[name] = deletedCNAMEs[name] || strings.EqualFold(change.Current.Type, "CNAME")
+		}
+	}
+
+	rank := func(change Change) int {
+		switch change.Action {
+		case ChangeUpdate:
+			return 0
+		case ChangeCreate:
+			name := NormalizeName(change.Desired.Name, zone)
+			if deletedCNAMEs[name] || (deletedNames[name] && strings.EqualFold(change.Desired.Type, "CNAME")) {
+				return 3
+			}
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	ordered := append([]Change{}, changes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		return changeKey(a) < changeKey(b)
+	})
+
+	return ordered
+}
+
+func changeKey(change Change) string {
+	record := change.Desired
+	if change.Action == ChangeDelete {
+		record = change.Current
+	}
+	return record.Name + "\x00" + record.Type + "\x00" + record.Value
+}
+
+// NormalizeName returns the lowercase name relative to the zone, treating "@", "" and the zone itself as the apex.
+// Names and zones may be given with or without a trailing dot.
+func NormalizeName(name string, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	if name == "@" || name == zone {
+		return ""
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+func formatRecord(record libdns.Record) string {
+	name := record.Name
+	if name == "" {
+		name = "@"
+	}
+
+	formatted := fmt.Sprintf("%s %s %s ttl=%s", record.Type, name, record.Value, record.TTL)
+	if record.Priority != 0 {
+		formatted += fmt.Sprintf(" priority=%d", record.Priority)
+	}
+	return formatted
+}
@@ -0,0 +1,178 @@
+package hosttech
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffRecords(t *testing.T) {
+	zone := "example.com"
+	current := []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second},
+		{ID: "2", Type: "A", Name: "api", Value: "1.2.3.5", TTL: 3600 * time.Second},
+		{ID: "3", Type: "TXT", Name: "", Value: "v=spf1 -all", TTL: 3600 * time.Second},
+		{ID: "4", Type: "A", Name: "shop", Value: "1.2.3.6", TTL: 3600 * time.Second},
+	}
+
+	input := map[string]struct {
+		expectedResult []Change
+		desired        []libdns.Record
+	}{
+		"No Changes Test": {
+			expectedResult: []Change{},
+			desired: []libdns.Record{
+				{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second},
+				{Type: "A", Name: "api", Value: "1.2.3.5"},
+				{Type: "TXT", Name: "@", Value: "v=spf1 -all", TTL: 3600 * time.Second},
+				{Type: "A", Name: "shop", Value: "1.2.3.6", TTL: 3600 * time.Second},
+			},
+		},
+		"Mixed Changes Test": {
+			expectedResult: []Change{
+				{Action: ChangeUpdate, Current: current[1], Desired: libdns.Record{Type: "A", Name: "api", Value: "1.2.3.9", TTL: 3600 * time.Second}},
+				{Action: ChangeUpdate, Current: current[0], Desired: libdns.Record{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second}},
+				{Action: ChangeDelete, Current: current[3]},
+				{Action: ChangeCreate, Desired: libdns.Record{Type: "CNAME", Name: "shop", Value: "shops.example.net", TTL: 3600 * time.Second}},
+			},
+			desired: []libdns.Record{
+				{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+				{Type: "A", Name: "api", Value: "1.2.3.9", TTL: 3600 * time.Second},
+				{Type: "TXT", Name: "", Value: "v=spf1 -all", TTL: 3600 * time.Second},
+				{Type: "CNAME", Name: "shop", Value: "shops.example.net", TTL: 3600 * time.Second},
+			},
+		},
+		"Creations Before Deletions Test": {
+			expectedResult: []Change{
+				{Action: ChangeCreate, Desired: libdns.Record{Type: "A", Name: "blog", Value: "1.2.3.7", TTL: 3600 * time.Second}},
+				{Action: ChangeCreate, Desired: libdns.Record{Type: "AAAA", Name: "shop", Value: "2001:db8::1", TTL: 3600 * time.Second}},
+				{Action: ChangeDelete, Current: current[3]},
+			},
+			desired: []libdns.Record{
+				{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second},
+				{Type: "A", Name: "api", Value: "1.2.3.5", TTL: 3600 * time.Second},
+				{Type: "TXT", Name: "", Value: "v=spf1 -all", TTL: 3600 * time.Second},
+				{Type: "A", Name: "blog", Value: "1.2.3.7", TTL: 3600 * time.Second},
+				{Type: "AAAA", Name: "shop", Value: "2001:db8::1", TTL: 3600 * time.Second},
+			},
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			output := DiffRecords(zone, current, testStruct.desired)
+
+			assert.Equal(t, testStruct.expectedResult, output)
+		})
+	}
+}
+
+func TestDiffRecords_SameNameAndType(t *testing.T) {
+	zone := "example.com."
+	current := []libdns.Record{
+		{ID: "1", Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second},
+		{ID: "2", Type: "A", Name: "www", Value: "1.2.3.5", TTL: 3600 * time.Second},
+	}
+
+	input := map[string]struct {
+		expectedResult []Change
+		desired        []libdns.Record
+	}{
+		"Value Paired First Test": {
+			expectedResult: []Change{
+				{Action: ChangeUpdate, Current: current[0], Desired: libdns.Record{Type: "A", Name: "WWW.Example.com.", Value: "1.2.3.9"}},
+			},
+			desired: []libdns.Record{
+				{Type: "A", Name: "WWW.Example.com.", Value: "1.2.3.5"},
+				{Type: "A", Name: "WWW.Example.com.", Value: "1.2.3.9"},
+			},
+		},
+		"Ambiguous Update Test": {
+			expectedResult: []Change{
+				{Action: ChangeCreate, Desired: libdns.Record{Type: "A", Name: "www", Value: "1.2.3.9"}},
+				{Action: ChangeDelete, Current: current[0]},
+				{Action: ChangeDelete, Current: current[1]},
+			},
+			desired: []libdns.Record{
+				{Type: "A", Name: "www", Value: "1.2.3.9"},
+			},
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			output := DiffRecords(zone, current, testStruct.desired)
+
+			assert.Equal(t, testStruct.expectedResult, output)
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	input := map[string]struct {
+		expectedResult string
+		name           string
+		zone           string
+	}{
+		"Relative Name Test":          {expectedResult: "www", name: "www", zone: "example.com"},
+		"Apex Test":                   {expectedResult: "", name: "@", zone: "example.com"},
+		"FQDN Test":                   {expectedResult: "www", name: "www.example.com.", zone: "example.com"},
+		"Zone With Trailing Dot Test": {expectedResult: "www", name: "www.example.com.", zone: "example.com."},
+		"Zone As Name Test":           {expectedResult: "", name: "example.com", zone: "example.com."},
+		"Mixed Case Test":             {expectedResult: "www", name: "WWW.Example.COM.", zone: "example.com"},
+		"Label Boundary Test":         {expectedResult: "wwwexample.com", name: "wwwexample.com", zone: "example.com"},
+		"Name Outside Of Zone Test":   {expectedResult: "www.example.org", name: "www.example.org.", zone: "example.com"},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testStruct.expectedResult, NormalizeName(testStruct.name, testStruct.zone))
+		})
+	}
+}
+
+func TestProvider_Apply(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "www", "ipv4": "1.2.3.4", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "api", "ipv4": "1.2.3.5", "ttl": 3600})
+	api.AddRecord("example.com", map[string]any{"type": "A", "name": "shop", "ipv4": "1.2.3.6", "ttl": 3600})
+
+	provider := Provider{APIToken: "token", APIURL: api.URL}
+	ctx := context.Background()
+
+	plan, err := provider.Plan(ctx, "example.com", []libdns.Record{
+		{Type: "A", Name: "www", Value: "1.2.3.4"},
+		{Type: "A", Name: "api", Value: "1.2.3.9"},
+		{Type: "CNAME", Name: "shop", Value: "shops.example.net", TTL: 3600 * time.Second},
+	})
+	assert.NoError(t, err)
+
+	applied, err := provider.Apply(ctx, plan)
+	assert.NoError(t, err)
+	assert.Equal(t, plan.Changes, applied)
+
+	records := api.Records("example.com")
+	assert.Len(t, records, 3)
+	assert.Equal(t, "www", records[0]["name"])
+	assert.Equal(t, "api", records[1]["name"])
+	assert.Equal(t, "1.2.3.9", records[1]["ipv4"])
+	assert.Equal(t, float64(3600), records[1]["ttl"])
+	assert.Equal(t, "CNAME", records[2]["type"])
+	assert.Equal(t, "shops.example.net", records[2]["cname"])
+
+	plan, err = provider.Plan(ctx, "example.com", []libdns.Record{
+		{Type: "A", Name: "www", Value: "1.2.3.4"},
+		{Type: "A", Name: "api", Value: "1.2.3.9"},
+		{Type: "CNAME", Name: "shop", Value: "shops.example.net"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), plan.String())
+
+	_, err = provider.Apply(ctx, Plan{Zone: "example.com", Changes: []Change{{Action: "rename"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+package hosttech
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The name of the tracer and meter, the import path of this package as recommended by OpenTelemetry
+const instrumentationName = "github.com/libdns/hosttech"
+
+// telemetry holds the tracer and the metric instruments of a provider.
+type telemetry struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	retries  metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// telemetry returns the tracer and instruments, creating them from the configured or global providers on first use.
+// The global providers do nothing unless the application configures them.
+func (p *Provider) telemetry() *telemetry {
+	p.telemetryOnce.Do(func() {
+		tracerProvider := p.TracerProvider
+		if tracerProvider == nil {
+			tracerProvider = otel.GetTracerProvider()
+		}
+		meterProvider := p.MeterProvider
+		if meterProvider == nil {
+			meterProvider = otel.GetMeterProvider()
+		}
+
+		meter := meterProvider.Meter(instrumentationName)
+		t := &telemetry{tracer: tracerProvider.Tracer(instrumentationName)}
+
+		//Instruments are never nil, on error a no-op instrument is returned along with it
+		t.requests, _ = meter.Int64Counter("hosttech.api.requests",
+			metric.WithDescription("Number of requests sent to the Hosttech API"),
+			metric.WithUnit("{request}"))
+		t.errors, _ = meter.Int64Counter("hosttech.api.errors",
+			metric.WithDescription("Number of failed requests to the Hosttech API, by status code"),
+			metric.WithUnit("{request}"))
+		t.retries, _ = meter.Int64Counter("hosttech.api.retries",
+			metric.WithDescription("Number of requests to the Hosttech API that were sent again after a failure"),
+			metric.WithUnit("{request}"))
+		t.duration, _ = meter.Float64Histogram("hosttech.api.request.duration",
+			metric.WithDescription("Duration of requests to the Hosttech API"),
+			metric.WithUnit("s"))
+
+		p.telemetryState = t
+	})
+
+	return p.telemetryState
+}
+
+// startSpan starts the span of a public method for the zone and records. The returned function ends the span and
+// records the error, if any.
+func (p *Provider) startSpan(ctx context.Context, method string, zone string, records []libdns.Record) (context.Context, func(*error)) {
+	attrs := []attribute.KeyValue{attribute.String("hosttech.zone", zone)}
+	if records != nil {
+		attrs = append(attrs,
+			attribute.Int("hosttech.records", len(records)),
+			attribute.StringSlice("hosttech.record_types", recordTypes(records)),
+		)
+	}
+
+	ctx, span := p.telemetry().tracer.Start(ctx, "hosttech."+method, trace.WithAttributes(attrs...))
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, p.redact((*err).Error()))
+		}
+		span.End()
+	}
+}
+
+// startApiCallSpan starts the client span of a single API request. The returned function ends the span with the
+// outcome of the request and records the metrics.
+func (p *Provider) startApiCallSpan(ctx context.Context, call apiCall) (context.Context, func(apiCall)) {
+	t := p.telemetry()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", call.method),
+		attribute.String("url.path", p.redact(apiPath(call.url))),
+		attribute.String("hosttech.zone", call.zone),
+	}
+	if recordType := requestRecordType(call.requestBody); recordType != "" {
+		attrs = append(attrs, attribute.String("hosttech.record_type", recordType))
+	}
+	if p.DryRun && call.method != http.MethodGet {
+		attrs = append(attrs, attribute.Bool("hosttech.dry_run", true))
+	}
+	if call.attempt > 1 {
+		attrs = append(attrs, attribute.Int("http.request.resend_count", call.attempt-1))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "HTTP "+call.method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return ctx, func(call apiCall) {
+		if call.status != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", call.status))
+		}
+		if call.err != nil {
+			span.RecordError(call.err)
+			span.SetStatus(codes.Error, p.redact(call.err.Error()))
+		}
+		span.End()
+
+		metricAttrs := metric.WithAttributes(
+			attribute.String("http.request.method", call.method),
+			attribute.String("http.response.status_code", statusLabel(call.status)),
+		)
+		t.requests.Add(ctx, 1, metricAttrs)
+		t.duration.Record(ctx, call.latency.Seconds(), metricAttrs)
+		if call.err != nil {
+			t.errors.Add(ctx, 1, metricAttrs)
+		}
+	}
+}
+
+// recordRetry counts a failed request that is about to be sent again.
+func (p *Provider) recordRetry(ctx context.Context, call apiCall) {
+	p.telemetry().retries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("http.request.method", call.method),
+		attribute.String("http.response.status_code", statusLabel(call.status)),
+	))
+}
+
+// statusLabel returns the status code as metric label. Requests that never got a response are labeled "none".
+func statusLabel(status int) string {
+	if status == 0 {
+		return "none"
+	}
+	return strconv.Itoa(status)
+}
+
+// requestRecordType returns the type of the record sent in a request body, if any.
+func requestRecordType(requestBody []byte) string {
+	var base Base
+	if len(requestBody) == 0 || json.Unmarshal(requestBody, &base) != nil {
+		return ""
+	}
+	return base.Type
+}
+
+func recordTypes(records []libdns.Record) []string {
+	seen := map[string]bool{}
+	types := []string{}
+	for _, record := range records {
+		if !seen[record.Type] {
+			seen[record.Type] = true
+			types = append(types, record.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
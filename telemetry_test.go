@@ -0,0 +1,113 @@
+package hosttech
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libdns/hosttech/hosttechtest"
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProvider_Telemetry(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	provider := Provider{
+		APIToken:       "token",
+		APIURL:         api.URL,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+
+	_, err := provider.AppendRecords(context.Background(), "example.com", []libdns.Record{
+		{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 3600 * time.Second},
+	})
+	assert.NoError(t, err)
+	_, err = provider.GetRecords(context.Background(), "unknown.com")
+	assert.Error(t, err)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 4)
+
+	//The request span is a child of the method span
+	assert.Equal(t, "HTTP POST", ended[0].Name())
+	assert.Equal(t, "hosttech.AppendRecords", ended[1].Name())
+	assert.Equal(t, ended[1].SpanContext().SpanID(), ended[0].Parent().SpanID())
+	assert.Contains(t, ended[0].Attributes(), attribute.String("hosttech.record_type", "A"))
+	assert.Contains(t, ended[0].Attributes(), attribute.Int("http.response.status_code", 201))
+	assert.Contains(t, ended[1].Attributes(), attribute.String("hosttech.zone", "example.com"))
+	assert.Equal(t, "hosttech.GetRecords", ended[3].Name())
+	assert.Equal(t, "Error", ended[3].Status().Code.String())
+
+	data := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	sums := map[string]int64{}
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+			for _, point := range sum.DataPoints {
+				sums[m.Name] += point.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"hosttech.api.requests": 2, "hosttech.api.errors": 1}, sums)
+}
+
+func TestProvider_TelemetryRetries(t *testing.T) {
+	api := hosttechtest.NewServer("token", "example.com")
+	defer api.Close()
+	failed := false
+	next := api.Config.Handler
+	api.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	provider := Provider{
+		APIToken:       "token",
+		APIURL:         api.URL,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+
+	_, err := provider.GetRecords(context.Background(), "example.com")
+	assert.NoError(t, err)
+
+	ended := spans.Ended()
+	assert.Len(t, ended, 3)
+	assert.NotContains(t, ended[0].Attributes(), attribute.Int("http.request.resend_count", 0))
+	assert.Contains(t, ended[1].Attributes(), attribute.Int("http.request.resend_count", 1))
+
+	data := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &data))
+	var retries int64
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "hosttech.api.retries" {
+			for _, point := range sum.DataPoints {
+				retries += point.Value
+				assert.Equal(t, attribute.NewSet(
+					attribute.String("http.request.method", "GET"),
+					attribute.String("http.response.status_code", "503"),
+				), point.Attributes)
+			}
+		}
+	}
+	assert.EqualValues(t, 1, retries)
+}
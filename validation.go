@@ -0,0 +1,86 @@
+package hosttech
+
+import (
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// TTLPolicy controls what happens to records with a TTL below the minimum accepted by the API.
+type TTLPolicy string
+
+const (
+	// TTLReject fails the whole batch if a record has a TTL below the minimum. This is the default.
+	TTLReject TTLPolicy = ""
+	// TTLClamp raises TTLs below the minimum to the minimum.
+	TTLClamp TTLPolicy = "clamp"
+)
+
+// ValidationError is returned when a record does not satisfy the constraints of its type.
+type ValidationError struct {
+	Record libdns.Record
+	Reason error
+}
+
+func (v ValidationError) Error() string {
+	return fmt.Sprintf(`%s record "%s" is invalid: %s`, v.Record.Type, v.Record.Name, v.Reason)
+}
+
+func (v ValidationError) Unwrap() error {
+	return v.Reason
+}
+
+// ValidateRecords checks all records against the constraints of their type, as they would be sent to the API for
+// the zone. It returns the first violation found.
+func (p *Provider) ValidateRecords(zone string, records []libdns.Record) error {
+	for _, record := range records {
+		_, err := p.validatedRecord(zone, record, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toHosttechRecords converts and validates the records, applying the TTL policy. The comment of each record is
+// determined as described in recordComment. Either all records are valid or an error is returned.
+func (p *Provider) toHosttechRecords(zone string, records []libdns.Record, existingComments map[string]string) ([]HosttechRecord, error) {
+	var hosttechRecords []HosttechRecord
+	for _, record := range records {
+		comment, err := p.recordComment(zone, record, existingComments)
+		if err != nil {
+			return nil, err
+		}
+
+		hosttechRecord, err := p.validatedRecord(zone, record, comment)
+		if err != nil {
+			return nil, err
+		}
+
+		hosttechRecords = append(hosttechRecords, hosttechRecord)
+	}
+
+	return hosttechRecords, nil
+}
+
+func (p *Provider) validatedRecord(zone string, record libdns.Record, comment string) (HosttechRecord, error) {
+	if p.TTLPolicy == TTLClamp && record.TTL != 0 && record.TTL < MinimalTTL {
+		record.TTL = MinimalTTL
+	}
+
+	hosttechRecord, err := libdnsRecordToHosttechRecord(record, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	err = hosttechRecord.validate(zone)
+	if err != nil {
+		return nil, ValidationError{
+			Record: record,
+			Reason: err,
+		}
+	}
+
+	return hosttechRecord, nil
+}
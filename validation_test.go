@@ -0,0 +1,92 @@
+package hosttech
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_ValidateRecords(t *testing.T) {
+	input := map[string]struct {
+		expectedValid bool
+		policy        TTLPolicy
+		data          libdns.Record
+	}{
+		"Valid ARecord Test": {
+			expectedValid: true,
+			data:          libdns.Record{Type: "A", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+		},
+		"Invalid IPv4 Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "A", Name: "www", Value: "1.2.3.400", TTL: 600 * time.Second},
+		},
+		"IPv6 In ARecord Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "A", Name: "www", Value: "2001:db8::1", TTL: 600 * time.Second},
+		},
+		"Valid AAAARecord Test": {
+			expectedValid: true,
+			data:          libdns.Record{Type: "AAAA", Name: "www", Value: "2001:db8::1", TTL: 600 * time.Second},
+		},
+		"IPv4 In AAAARecord Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "AAAA", Name: "www", Value: "1.2.3.4", TTL: 600 * time.Second},
+		},
+		"TTL Too Low Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "TXT", Name: "www", Value: "text", TTL: 300 * time.Second},
+		},
+		"TTL Clamped Test": {
+			expectedValid: true,
+			policy:        TTLClamp,
+			data:          libdns.Record{Type: "TXT", Name: "www", Value: "text", TTL: 300 * time.Second},
+		},
+		"Default TTL Test": {
+			expectedValid: true,
+			data:          libdns.Record{Type: "TXT", Name: "www", Value: "text"},
+		},
+		"CNAME At Apex Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "CNAME", Name: "@", Value: "site.example.com", TTL: 600 * time.Second},
+		},
+		"CNAME At FQDN Apex Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "CNAME", Name: "example.com.", Value: "site.example.com", TTL: 600 * time.Second},
+		},
+		"CNAME At Unqualified FQDN Apex Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "CNAME", Name: "example.com", Value: "site.example.com", TTL: 600 * time.Second},
+		},
+		"CNAME At Mixed Case FQDN Apex Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "CNAME", Name: "Example.COM.", Value: "site.example.com", TTL: 600 * time.Second},
+		},
+		"CNAME Below Apex Test": {
+			expectedValid: true,
+			data:          libdns.Record{Type: "CNAME", Name: "www.example.com.", Value: "site.example.com", TTL: 600 * time.Second},
+		},
+		"MX Priority Out Of Range Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "MX", Name: "", Value: "mail.example.com", TTL: 600 * time.Second, Priority: 70000},
+		},
+		"Invalid TLSARecord Test": {
+			expectedValid: false,
+			data:          libdns.Record{Type: "TLSA", Name: "_443._tcp", Value: "TLSA text", TTL: 600 * time.Second},
+		},
+	}
+
+	for name, testStruct := range input {
+		t.Run(name, func(t *testing.T) {
+			provider := Provider{TTLPolicy: testStruct.policy}
+			err := provider.ValidateRecords("example.com", []libdns.Record{testStruct.data})
+
+			if testStruct.expectedValid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorAs(t, err, &ValidationError{})
+			}
+		})
+	}
+}
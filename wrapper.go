@@ -23,7 +23,7 @@ func (h HosttechRecordWrapper) toLibdnsRecord(zone string) libdns.Record {
 }
 
 func (h HosttechRecordWrapper) fromLibdnsRecord(record libdns.Record) {
-	h.value.fromLibdnsRecord(record)
+	h.value.fromLibdnsRecord(record, generateComment())
 }
 
 func (h *HosttechRecordWrapper) UnmarshalJSON(b []byte) error {
@@ -72,6 +72,10 @@ func (h *HosttechRecordWrapper) UnmarshalJSON(b []byte) error {
 }
 
 func LibdnsRecordToHosttechRecordWrapper(record libdns.Record) (HosttechRecord, error) {
+	return libdnsRecordToHosttechRecord(record, generateComment())
+}
+
+func libdnsRecordToHosttechRecord(record libdns.Record, comment string) (HosttechRecord, error) {
 	var hosttechRecord HosttechRecord
 
 	switch record.Type {
@@ -93,5 +97,5 @@ func LibdnsRecordToHosttechRecordWrapper(record libdns.Record) (HosttechRecord,
 		return nil, fmt.Errorf(`record type "%s" is not supported"`, record.Type)
 	}
 
-	return hosttechRecord.fromLibdnsRecord(record), nil
+	return hosttechRecord.fromLibdnsRecord(record, comment), nil
 }